@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-recaptcha/internal/logger"
+)
+
+// envInt reads a positive integer from the environment, falling back to def when unset or invalid.
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		logger.Log.Warn("invalid integer in environment, using default", "variable", name, "default", def)
+		return def
+	}
+	return parsed
+}
+
+// envFloat reads a positive float from the environment, falling back to def when unset or invalid.
+func envFloat(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed <= 0 {
+		logger.Log.Warn("invalid number in environment, using default", "variable", name, "default", def)
+		return def
+	}
+	return parsed
+}
+
+// envSeconds reads a number of seconds from the environment as a duration.
+func envSeconds(name string, def time.Duration) time.Duration {
+	return time.Duration(envInt(name, int(def/time.Second))) * time.Second
+}
+
+// envMillis reads a number of milliseconds from the environment as a duration.
+func envMillis(name string, def time.Duration) time.Duration {
+	return time.Duration(envInt(name, int(def/time.Millisecond))) * time.Millisecond
+}
+
+// envList splits a comma-separated environment variable, dropping empty items.
+func envList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
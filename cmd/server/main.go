@@ -13,7 +13,9 @@ import (
 
 	"api-recaptcha/internal/handler"
 	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/metrics"
 	"api-recaptcha/internal/middleware"
+	"api-recaptcha/internal/policy"
 	"api-recaptcha/internal/service"
 )
 
@@ -29,44 +31,119 @@ func main() {
 		os.Exit(1)
 	}
 
-	googleAPIKey := os.Getenv("GOOGLE_RECAPTCHA_API_KEY")
-	if googleAPIKey == "" {
-		logger.Log.Error("GOOGLE_RECAPTCHA_API_KEY environment variable is required")
-		os.Exit(1)
+	if tenantsFile := os.Getenv("TENANTS_FILE"); tenantsFile != "" {
+		tenantDirectory = buildTenantDirectory(tenantsFile)
 	}
 
-	siteKey := os.Getenv("GOOGLE_RECAPTCHA_SITE_KEY")
-	if siteKey == "" {
-		logger.Log.Warn("GOOGLE_RECAPTCHA_SITE_KEY not set, using default (not recommended for production)")
-		siteKey = "6LfTUuorAAAAAEYi8wmrchk8zaxcasstljmj-ZZT"
+	providerName, assessor, siteKeys := buildAssessor()
+	providers := buildProviderRegistry(providerName, assessor, siteKeys)
+	enterprise, enterpriseEnabled := enterpriseService(providers)
+	assessor = buildFailoverChain(providers, assessor)
+
+	if os.Getenv("REPLAY_PROTECTION_ENABLED") != "false" {
+		replayStore := service.NewMemoryReplayStore()
+		defer replayStore.Stop()
+
+		replayTTL := envSeconds("REPLAY_TOKEN_TTL_SECONDS", service.DefaultReplayTTL)
+		guard := func(next service.Assessor) service.Assessor {
+			return service.NewReplayGuard(next, replayStore, replayTTL)
+		}
+		assessor = guard(assessor)
+		providers.Wrap(guard)
+		logger.Log.Info("token replay protection enabled", "ttl", replayTTL.String())
 	}
 
-	projectID := os.Getenv("GOOGLE_RECAPTCHA_PROJECT_ID")
-	if projectID == "" {
-		logger.Log.Error("GOOGLE_RECAPTCHA_PROJECT_ID environment variable is required")
-		os.Exit(1)
+	if os.Getenv("COALESCE_ASSESSMENTS_ENABLED") != "false" {
+		coalesce := func(next service.Assessor) service.Assessor {
+			return service.NewSingleflight(next)
+		}
+		assessor = coalesce(assessor)
+		providers.Wrap(coalesce)
+		logger.Log.Info("concurrent assessment coalescing enabled")
 	}
 
-	recaptchaEndpoint := "https://recaptchaenterprise.googleapis.com/v1/projects/" + projectID + "/assessments"
+	if os.Getenv("RESULT_CACHE_ENABLED") == "true" {
+		resultCache := service.NewMemoryResultCache()
+		defer resultCache.Stop()
 
-	recaptchaService := service.NewRecaptchaService(googleAPIKey, siteKey, recaptchaEndpoint)
-	verifyHandler := handler.NewVerifyHandler(recaptchaService)
+		cacheTTL := envSeconds("RESULT_CACHE_TTL_SECONDS", service.DefaultResultCacheTTL)
+		cacheHits := envInt("RESULT_CACHE_MAX_HITS", service.DefaultResultCacheHits)
+		cached := func(next service.Assessor) service.Assessor {
+			return service.NewCachingAssessor(next, resultCache, cacheTTL, cacheHits)
+		}
+		assessor = cached(assessor)
+		providers.Wrap(cached)
+		logger.Log.Info("assessment result cache enabled", "ttl", cacheTTL.String(), "max_hits", cacheHits)
+	}
+
+	policies := policy.NewDefaultEngine()
+	if policyFile := os.Getenv("POLICY_FILE"); policyFile != "" {
+		loaded, err := policy.LoadFile(policyFile)
+		if err != nil {
+			logger.Log.Error("failed to load score policies", "file", policyFile, "error", err)
+			os.Exit(1)
+		}
+		policies = loaded
+		logger.Log.Info("score policies loaded", "file", policyFile)
+	}
+	registerMetricActions(policies)
+
+	verifyOpts := []handler.VerifyOption{
+		handler.WithStrictMode(os.Getenv("STRICT_TOKEN_VALIDATION") == "true"),
+		handler.WithClientSignals(handler.ClientSignalsConfig{
+			Enabled:   os.Getenv("CLIENT_SIGNALS_ENABLED") == "true",
+			JA3Header: os.Getenv("CLIENT_SIGNALS_JA3_HEADER"),
+			Headers:   envList("CLIENT_SIGNALS_HEADERS"),
+		}),
+	}
+	verifyHandler := handler.NewVerifyHandler(assessor, policies, verifyOpts...)
+	captchaHandler := handler.NewCaptchaHandler(providers, policies, verifyOpts...)
 
 	rateLimiter := middleware.NewRateLimiter()
 	defer rateLimiter.Stop()
+	tenantRateLimiter := middleware.NewTenantRateLimiter()
+	defer tenantRateLimiter.Stop()
+
+	var tenantOrigins []string
+	if tenantDirectory != nil {
+		tenantOrigins = tenantDirectory.Origins()
+	}
 
 	router := gin.Default()
-	router.Use(middleware.CORS())
+	// X-Forwarded-For is only honored from TRUSTED_PROXIES; otherwise ClientIP is the peer
+	// address, so callers cannot choose the IP used for rate limiting and client signals.
+	if err := router.SetTrustedProxies(envList("TRUSTED_PROXIES")); err != nil {
+		logger.Log.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+	router.Use(middleware.CORS(tenantOrigins...))
 
 	// Health check endpoints (no authentication required)
 	router.GET("/health", handler.HealthCheck)
-	router.GET("/ready", handler.ReadinessCheck)
+	router.GET("/ready", handler.ReadinessCheck(circuitBreakers, failoverChain))
+
+	// Service counters, for operators only
+	router.GET("/metrics", middleware.APIKeyAuth(appAPIKey), gin.WrapH(metrics.Handler()))
 
 	// API endpoints (with rate limiting and authentication)
 	api := router.Group("/api/v1")
 	api.Use(rateLimiter.RateLimit())
-	api.Use(middleware.APIKeyAuth(appAPIKey))
+	appAPIKeys := []string{appAPIKey}
+	if siteRegistry != nil {
+		appAPIKeys = append(appAPIKeys, siteRegistry.AppAPIKeys()...)
+	}
+	api.Use(middleware.TenantAPIKeyAuth(tenantDirectory, appAPIKeys...))
+	api.Use(tenantRateLimiter.RateLimit())
 	api.POST("/recaptcha/verify", verifyHandler.Handle)
+	api.POST("/captcha/verify", captchaHandler.Handle)
+	if enterpriseEnabled {
+		// Transactions are an Enterprise feature, so checkouts never fail over to another provider.
+		checkoutAssessor, _ := providers.Lookup(service.ProviderEnterprise)
+		api.POST("/recaptcha/checkout", handler.NewCheckoutHandler(checkoutAssessor, policies, verifyOpts...).Handle)
+		api.POST("/recaptcha/password-leak", handler.NewPasswordLeakHandler(enterprise).Handle)
+		api.POST("/recaptcha/assessments/:id/annotate", handler.NewAnnotateHandler(enterprise).Handle)
+		api.POST("/recaptcha/express", handler.NewExpressHandler(enterprise, policies, verifyOpts...).Handle)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
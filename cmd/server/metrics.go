@@ -0,0 +1,20 @@
+package main
+
+import (
+	"api-recaptcha/internal/metrics"
+	"api-recaptcha/internal/policy"
+)
+
+// registerMetricActions reports the actions with a policy rule, global or per tenant, by name
+// in the action-keyed counters; every other action is counted as "other".
+func registerMetricActions(policies *policy.Engine) {
+	metrics.RegisterActions(policies.Actions()...)
+	if tenantDirectory == nil {
+		return
+	}
+	for _, t := range tenantDirectory.Tenants() {
+		if t.Policies != nil {
+			metrics.RegisterActions(t.Policies.Actions()...)
+		}
+	}
+}
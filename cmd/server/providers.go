@@ -0,0 +1,313 @@
+package main
+
+import (
+	"os"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/service"
+	"api-recaptcha/internal/tenant"
+)
+
+// providerCredentials lists the environment variable holding each provider's secret.
+// A provider is only registered on the generic captcha route when its secret is set.
+var providerCredentials = map[string]string{
+	service.ProviderEnterprise: "GOOGLE_RECAPTCHA_API_KEY",
+	service.ProviderSiteVerify: "GOOGLE_RECAPTCHA_SECRET_KEY",
+	service.ProviderHCaptcha:   "HCAPTCHA_SECRET_KEY",
+	service.ProviderTurnstile:  "TURNSTILE_SECRET_KEY",
+}
+
+// circuitBreakers collects the breakers created for upstream providers so /ready can report them.
+var circuitBreakers = map[string]*service.CircuitBreaker{}
+
+// buildAssessor selects the verification provider configured in RECAPTCHA_PROVIDER and
+// returns it with the site keys its tokens belong to.
+func buildAssessor() (string, service.Assessor, []string) {
+	provider := os.Getenv("RECAPTCHA_PROVIDER")
+	if provider == "" {
+		provider = service.ProviderEnterprise
+	}
+
+	if _, ok := providerCredentials[provider]; !ok {
+		logger.Log.Error("unknown RECAPTCHA_PROVIDER", "provider", provider)
+		os.Exit(1)
+	}
+
+	logger.Log.Info("using verification provider", "provider", provider)
+
+	assessor, siteKeys := buildProvider(provider)
+	return provider, assessor, siteKeys
+}
+
+// providerConfigured reports whether the credentials of a provider are set. Enterprise may also
+// authenticate with a service account.
+func providerConfigured(name, secretEnv string) bool {
+	if name == service.ProviderEnterprise && os.Getenv("GOOGLE_SERVICE_ACCOUNT_FILE") != "" {
+		return true
+	}
+	return os.Getenv(secretEnv) != ""
+}
+
+// buildProviderRegistry registers the primary provider plus every other provider with credentials,
+// so /api/v1/captcha/verify can route requests by provider name or site key.
+func buildProviderRegistry(primaryName string, primary service.Assessor, primarySiteKeys []string) *service.ProviderRegistry {
+	defaultProvider := os.Getenv("CAPTCHA_DEFAULT_PROVIDER")
+	if defaultProvider == "" {
+		defaultProvider = primaryName
+	}
+
+	registry := service.NewProviderRegistry(defaultProvider)
+	registry.Register(primaryName, primary, primarySiteKeys...)
+
+	for name, secretEnv := range providerCredentials {
+		if name == primaryName || !providerConfigured(name, secretEnv) {
+			continue
+		}
+		assessor, siteKeys := buildProvider(name)
+		registry.Register(name, assessor, siteKeys...)
+	}
+
+	if _, _, err := registry.Resolve("", ""); err != nil {
+		logger.Log.Error("CAPTCHA_DEFAULT_PROVIDER is not configured", "provider", defaultProvider)
+		os.Exit(1)
+	}
+
+	logger.Log.Info("captcha providers registered",
+		"providers", registry.Providers(),
+		"default", defaultProvider,
+	)
+
+	return registry
+}
+
+// enterpriseFeatures groups the features only reCAPTCHA Enterprise offers. Both a single
+// RecaptchaService and a SiteRegistry provide them.
+type enterpriseFeatures interface {
+	service.ExpressAssessor
+	service.Annotator
+	service.PasswordLeakChecker
+}
+
+// enterpriseService returns the reCAPTCHA Enterprise provider when it is registered, for the
+// features only Enterprise offers. It must run before the registry is wrapped.
+func enterpriseService(providers *service.ProviderRegistry) (enterpriseFeatures, bool) {
+	enterprise, ok := providers.Lookup(service.ProviderEnterprise)
+	if !ok {
+		return nil, false
+	}
+	features, ok := enterprise.(enterpriseFeatures)
+	return features, ok
+}
+
+// buildProvider creates the Assessor for the given provider and returns the site keys its tokens belong to.
+func buildProvider(name string) (service.Assessor, []string) {
+	switch name {
+	case service.ProviderSiteVerify:
+		return buildSiteVerifyService(), []string{providerSiteKey(name)}
+	case service.ProviderHCaptcha:
+		return buildHCaptchaService(), []string{providerSiteKey(name)}
+	case service.ProviderTurnstile:
+		return buildTurnstileService(), []string{providerSiteKey(name)}
+	default:
+		var enterprise tenant.EnterpriseProvider
+		var siteKeys []string
+		if sitesFile := os.Getenv("SITES_FILE"); sitesFile != "" {
+			sites := buildSiteRegistry(sitesFile)
+			enterprise, siteKeys = sites, sites.SiteKeys()
+		} else {
+			enterprise, siteKeys = buildEnterpriseService(), []string{providerSiteKey(name)}
+		}
+		if tenantDirectory != nil {
+			return tenant.NewAssessor(enterprise), append(siteKeys, tenantDirectory.SiteKeys()...)
+		}
+		return enterprise, siteKeys
+	}
+}
+
+// providerSiteKey returns the public site key configured for the provider, if any.
+func providerSiteKey(name string) string {
+	switch name {
+	case service.ProviderSiteVerify:
+		return os.Getenv("GOOGLE_RECAPTCHA_CLASSIC_SITE_KEY")
+	case service.ProviderHCaptcha:
+		return os.Getenv("HCAPTCHA_SITE_KEY")
+	case service.ProviderTurnstile:
+		return os.Getenv("TURNSTILE_SITE_KEY")
+	default:
+		return os.Getenv("GOOGLE_RECAPTCHA_SITE_KEY")
+	}
+}
+
+// buildEnterpriseService configures the reCAPTCHA Enterprise assessments client.
+func buildEnterpriseService() *service.RecaptchaService {
+	googleAPIKey := os.Getenv("GOOGLE_RECAPTCHA_API_KEY")
+	serviceAccountFile := os.Getenv("GOOGLE_SERVICE_ACCOUNT_FILE")
+	if googleAPIKey == "" && serviceAccountFile == "" {
+		logger.Log.Error("GOOGLE_RECAPTCHA_API_KEY or GOOGLE_SERVICE_ACCOUNT_FILE environment variable is required")
+		os.Exit(1)
+	}
+
+	siteKey := os.Getenv("GOOGLE_RECAPTCHA_SITE_KEY")
+	if siteKey == "" {
+		logger.Log.Warn("GOOGLE_RECAPTCHA_SITE_KEY not set, using default (not recommended for production)")
+		siteKey = "6LfTUuorAAAAAEYi8wmrchk8zaxcasstljmj-ZZT"
+	}
+
+	projectID := os.Getenv("GOOGLE_RECAPTCHA_PROJECT_ID")
+	if projectID == "" {
+		logger.Log.Error("GOOGLE_RECAPTCHA_PROJECT_ID environment variable is required")
+		os.Exit(1)
+	}
+
+	constraints := service.TokenConstraints{
+		MaxAge:              envSeconds("RECAPTCHA_TOKEN_MAX_AGE_SECONDS", 0),
+		Hostnames:           envList("RECAPTCHA_ALLOWED_HOSTNAMES"),
+		AndroidPackageNames: envList("RECAPTCHA_ALLOWED_ANDROID_PACKAGES"),
+		IOSBundleIDs:        envList("RECAPTCHA_ALLOWED_IOS_BUNDLES"),
+	}
+	opts := enterpriseOptions(service.ProviderEnterprise, constraints, os.Getenv("GOOGLE_RECAPTCHA_EXPRESS_SITE_KEY"))
+	if serviceAccountFile != "" {
+		tokens, err := serviceAccountTokenSource(serviceAccountFile)
+		if err != nil {
+			logger.Log.Error("failed to load GOOGLE_SERVICE_ACCOUNT_FILE", "error", err)
+			os.Exit(1)
+		}
+		opts = append(opts, service.WithTokenSource(tokens))
+	}
+
+	return service.NewRecaptchaService(googleAPIKey, siteKey, service.EnterpriseAssessmentsEndpoint(projectID), opts...)
+}
+
+// enterpriseOptions builds the options shared by every reCAPTCHA Enterprise client. The circuit
+// breaker is registered under breakerName so /ready reports each site separately.
+func enterpriseOptions(breakerName string, constraints service.TokenConstraints, expressSiteKey string) []service.Option {
+	opts := []service.Option{
+		service.WithRetryPolicy(service.RetryPolicy{
+			MaxAttempts: envInt("RETRY_MAX_ATTEMPTS", service.DefaultRetryMaxAttempts),
+			BaseDelay:   envMillis("RETRY_BASE_DELAY_MS", service.DefaultRetryBaseDelay),
+			MaxDelay:    envMillis("RETRY_MAX_DELAY_MS", service.DefaultRetryMaxDelay),
+			Budget:      envSeconds("RETRY_BUDGET_SECONDS", service.DefaultRetryBudget),
+		}),
+		service.WithTokenConstraints(constraints),
+		service.WithHTTPClient(outboundHTTPClient()),
+	}
+	if expressSiteKey != "" {
+		opts = append(opts, service.WithExpressSiteKey(expressSiteKey))
+	}
+	if salt := os.Getenv("ACCOUNT_DEFENDER_SALT"); salt != "" {
+		opts = append(opts, service.WithAccountDefender(salt))
+	}
+	if limiter := outboundLimiter(); limiter != nil {
+		opts = append(opts, service.WithOutboundLimiter(limiter))
+	}
+	if os.Getenv("HEDGE_ENABLED") == "true" {
+		opts = append(opts, service.WithHedging(service.HedgePolicy{
+			Percentile:   envFloat("HEDGE_PERCENTILE", service.DefaultHedgePercentile),
+			MinDelay:     envMillis("HEDGE_MIN_DELAY_MS", service.DefaultHedgeMinDelay),
+			InitialDelay: envMillis("HEDGE_INITIAL_DELAY_MS", service.DefaultHedgeInitialDelay),
+			Window:       envInt("HEDGE_WINDOW", service.DefaultHedgeWindow),
+		}))
+	}
+	if os.Getenv("CIRCUIT_BREAKER_ENABLED") != "false" {
+		breaker := service.NewCircuitBreaker(breakerName, service.BreakerConfig{
+			FailureRatio: envFloat("CIRCUIT_BREAKER_FAILURE_RATIO", service.DefaultBreakerFailureRatio),
+			MinRequests:  envInt("CIRCUIT_BREAKER_MIN_REQUESTS", service.DefaultBreakerMinRequests),
+			Window:       envSeconds("CIRCUIT_BREAKER_WINDOW_SECONDS", service.DefaultBreakerWindow),
+			Cooldown:     envSeconds("CIRCUIT_BREAKER_COOLDOWN_SECONDS", service.DefaultBreakerCooldown),
+		})
+		circuitBreakers[breakerName] = breaker
+		opts = append(opts, service.WithCircuitBreaker(breaker))
+	}
+
+	return opts
+}
+
+// serviceAccountTokenSources shares one token source, and its cached access token, per key file.
+var serviceAccountTokenSources = map[string]*service.ServiceAccountTokenSource{}
+
+// serviceAccountTokenSource loads the service account key at path. The token endpoint can be
+// overridden with GOOGLE_OAUTH_TOKEN_URL.
+func serviceAccountTokenSource(path string) (*service.ServiceAccountTokenSource, error) {
+	if tokens, ok := serviceAccountTokenSources[path]; ok {
+		return tokens, nil
+	}
+
+	key, err := service.LoadServiceAccountKey(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := service.NewServiceAccountTokenSource(key, os.Getenv("GOOGLE_OAUTH_TOKEN_URL"), outboundHTTPClient())
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccountTokenSources[path] = tokens
+	return tokens, nil
+}
+
+// buildSiteVerifyService configures the classic reCAPTCHA v2/v3 siteverify client.
+func buildSiteVerifyService() *service.SiteVerifyService {
+	secret := os.Getenv("GOOGLE_RECAPTCHA_SECRET_KEY")
+	if secret == "" {
+		logger.Log.Error("GOOGLE_RECAPTCHA_SECRET_KEY environment variable is required for the siteverify provider")
+		os.Exit(1)
+	}
+
+	return service.NewSiteVerifyService(secret, os.Getenv("GOOGLE_RECAPTCHA_SITEVERIFY_URL"),
+		service.WithVerifierHTTPClient(outboundHTTPClient()))
+}
+
+// buildHCaptchaService configures the hCaptcha client.
+func buildHCaptchaService() *service.HCaptchaService {
+	secret := os.Getenv("HCAPTCHA_SECRET_KEY")
+	if secret == "" {
+		logger.Log.Error("HCAPTCHA_SECRET_KEY environment variable is required for the hcaptcha provider")
+		os.Exit(1)
+	}
+
+	return service.NewHCaptchaService(secret, os.Getenv("HCAPTCHA_SITE_KEY"), os.Getenv("HCAPTCHA_VERIFY_URL"),
+		service.WithVerifierHTTPClient(outboundHTTPClient()))
+}
+
+// buildTurnstileService configures the Cloudflare Turnstile client.
+func buildTurnstileService() *service.TurnstileService {
+	secret := os.Getenv("TURNSTILE_SECRET_KEY")
+	if secret == "" {
+		logger.Log.Error("TURNSTILE_SECRET_KEY environment variable is required for the turnstile provider")
+		os.Exit(1)
+	}
+
+	return service.NewTurnstileService(secret, os.Getenv("TURNSTILE_VERIFY_URL"),
+		service.WithVerifierHTTPClient(outboundHTTPClient()))
+}
+
+// failoverChain is the FailoverAssessor built from CAPTCHA_FAILOVER_CHAIN, if any, so /ready can
+// report the health of its providers.
+var failoverChain *service.FailoverAssessor
+
+// buildFailoverChain wraps the providers listed in CAPTCHA_FAILOVER_CHAIN into a FailoverAssessor.
+// It returns the primary assessor unchanged when no chain is configured.
+func buildFailoverChain(providers *service.ProviderRegistry, primary service.Assessor) service.Assessor {
+	chain := envList("CAPTCHA_FAILOVER_CHAIN")
+	if len(chain) == 0 {
+		return primary
+	}
+
+	named := make([]service.NamedAssessor, 0, len(chain))
+	for _, name := range chain {
+		assessor, ok := providers.Lookup(name)
+		if !ok {
+			logger.Log.Error("provider in CAPTCHA_FAILOVER_CHAIN is not configured", "provider", name)
+			os.Exit(1)
+		}
+		named = append(named, service.NamedAssessor{Name: name, Assessor: assessor})
+	}
+
+	logger.Log.Info("provider failover enabled", "chain", chain)
+
+	failoverChain = service.NewFailoverAssessor(service.FailoverConfig{
+		FailureThreshold: envInt("CAPTCHA_FAILOVER_THRESHOLD", service.DefaultFailoverThreshold),
+		Cooldown:         envSeconds("CAPTCHA_FAILOVER_COOLDOWN_SECONDS", service.DefaultFailoverCooldown),
+	}, named...)
+	return failoverChain
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/service"
+)
+
+// siteRegistry is set when SITES_FILE configures several Enterprise sites, so their app API
+// keys are accepted by the API key middleware.
+var siteRegistry *service.SiteRegistry
+
+// buildSiteRegistry creates one reCAPTCHA Enterprise client per site listed in the sites file.
+func buildSiteRegistry(path string) *service.SiteRegistry {
+	cfg, err := service.LoadSitesFile(path)
+	if err != nil {
+		logger.Log.Error("failed to load SITES_FILE", "path", path, "error", err)
+		os.Exit(1)
+	}
+
+	registry, err := newSiteRegistry(service.ProviderEnterprise+":", cfg)
+	if err != nil {
+		logger.Log.Error("invalid site configuration", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Log.Info("reCAPTCHA Enterprise sites registered", "sites", registry.Sites(), "default", cfg.Default)
+
+	siteRegistry = registry
+	return registry
+}
+
+// newSiteRegistry creates the Enterprise clients of cfg. Circuit breakers are named
+// breakerPrefix followed by the site name.
+func newSiteRegistry(breakerPrefix string, cfg service.SitesConfig) (*service.SiteRegistry, error) {
+	registry := service.NewSiteRegistry(cfg.Default)
+	for _, site := range cfg.Sites {
+		if site.SiteKey == "" || site.ProjectID == "" {
+			return nil, fmt.Errorf("site %q requires siteKey and projectId", site.Name)
+		}
+
+		opts := enterpriseOptions(breakerPrefix+site.Name, site.Constraints(), site.ExpressSiteKey)
+
+		// The site's own credentials win over the deployment ones; a service account wins over an API key.
+		googleAPIKey := site.GoogleAPIKey
+		serviceAccountFile := site.ServiceAccountFile
+		if googleAPIKey == "" && serviceAccountFile == "" {
+			googleAPIKey = os.Getenv("GOOGLE_RECAPTCHA_API_KEY")
+			serviceAccountFile = os.Getenv("GOOGLE_SERVICE_ACCOUNT_FILE")
+		}
+		if serviceAccountFile != "" {
+			tokens, err := serviceAccountTokenSource(serviceAccountFile)
+			if err != nil {
+				return nil, fmt.Errorf("site %q: %w", site.Name, err)
+			}
+			opts = append(opts, service.WithTokenSource(tokens))
+		} else if googleAPIKey == "" {
+			return nil, fmt.Errorf("site %q requires a Google API key or service account", site.Name)
+		}
+
+		svc := service.NewRecaptchaService(googleAPIKey, site.SiteKey, service.EnterpriseAssessmentsEndpoint(site.ProjectID), opts...)
+		if err := registry.Register(site.Name, svc, site.Origins, site.AppAPIKeys); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, _, err := registry.Resolve(service.SiteSelector{}); err != nil {
+		return nil, fmt.Errorf("default site %q is not configured", cfg.Default)
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/service"
+	"api-recaptcha/internal/tenant"
+)
+
+// tenantDirectory is set when TENANTS_FILE enables multi-tenant mode.
+var tenantDirectory *tenant.Directory
+
+// buildTenantDirectory loads the tenants persisted in the local store at path.
+func buildTenantDirectory(path string) *tenant.Directory {
+	configs, err := tenant.NewFileStore(path).List()
+	if err != nil {
+		logger.Log.Error("failed to load TENANTS_FILE", "path", path, "error", err)
+		os.Exit(1)
+	}
+
+	directory, err := tenant.NewDirectory(configs, func(tenantID string, sites service.SitesConfig) (*service.SiteRegistry, error) {
+		return newSiteRegistry(service.ProviderEnterprise+":"+tenantID+"/", sites)
+	})
+	if err != nil {
+		logger.Log.Error("invalid tenant configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ids := make([]string, 0, len(directory.Tenants()))
+	for _, t := range directory.Tenants() {
+		ids = append(ids, t.ID)
+	}
+	logger.Log.Info("tenants loaded", "file", path, "tenants", ids)
+
+	return directory
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/service"
+)
+
+// outboundClient is shared by every Enterprise client so they use one connection pool.
+var outboundClient *http.Client
+
+// outboundHTTPClient builds the HTTP client for upstream calls from the OUTBOUND_* variables.
+func outboundHTTPClient() *http.Client {
+	if outboundClient != nil {
+		return outboundClient
+	}
+
+	client, err := service.NewHTTPClient(service.TransportConfig{
+		ProxyURL:              os.Getenv("OUTBOUND_PROXY_URL"),
+		CAFile:                os.Getenv("OUTBOUND_CA_FILE"),
+		ClientCertFile:        os.Getenv("OUTBOUND_CLIENT_CERT_FILE"),
+		ClientKeyFile:         os.Getenv("OUTBOUND_CLIENT_KEY_FILE"),
+		MinTLSVersion:         os.Getenv("OUTBOUND_TLS_MIN_VERSION"),
+		DisableHTTP2:          os.Getenv("OUTBOUND_HTTP2_ENABLED") == "false",
+		MaxIdleConns:          envInt("OUTBOUND_MAX_IDLE_CONNS", service.DefaultMaxIdleConns),
+		MaxIdleConnsPerHost:   envInt("OUTBOUND_MAX_IDLE_CONNS_PER_HOST", service.DefaultMaxIdleConnsPerHost),
+		MaxConnsPerHost:       envInt("OUTBOUND_MAX_CONNS_PER_HOST", 0),
+		IdleConnTimeout:       envSeconds("OUTBOUND_IDLE_CONN_TIMEOUT_SECONDS", service.DefaultIdleConnTimeout),
+		Timeout:               envMillis("OUTBOUND_TIMEOUT_MS", service.DefaultOutboundTimeout),
+		DialTimeout:           envMillis("OUTBOUND_DIAL_TIMEOUT_MS", service.DefaultDialTimeout),
+		TLSHandshakeTimeout:   envMillis("OUTBOUND_TLS_HANDSHAKE_TIMEOUT_MS", service.DefaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: envMillis("OUTBOUND_RESPONSE_HEADER_TIMEOUT_MS", 0),
+	})
+	if err != nil {
+		logger.Log.Error("invalid outbound transport configuration", "error", err)
+		os.Exit(1)
+	}
+
+	outboundClient = client
+	return client
+}
+
+// outboundLimiterInstance is shared by every Enterprise client: the quota belongs to the Google project.
+var outboundLimiterInstance *service.OutboundLimiter
+
+// outboundLimiter returns the outbound token bucket configured with OUTBOUND_QPS, or nil when unset.
+func outboundLimiter() *service.OutboundLimiter {
+	if outboundLimiterInstance != nil || os.Getenv("OUTBOUND_QPS") == "" {
+		return outboundLimiterInstance
+	}
+
+	qps := envFloat("OUTBOUND_QPS", 0)
+	if qps <= 0 {
+		return nil
+	}
+	outboundLimiterInstance = service.NewOutboundLimiter(service.LimiterConfig{
+		QPS:      qps,
+		Burst:    envInt("OUTBOUND_BURST", 0),
+		MaxQueue: envInt("OUTBOUND_QUEUE_SIZE", service.DefaultOutboundQueueSize),
+	})
+	logger.Log.Info("outbound rate limit enabled", "qps", qps)
+	return outboundLimiterInstance
+}
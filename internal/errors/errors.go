@@ -26,13 +26,32 @@ func (e *AppError) UserMessage() string {
 
 // Common error codes
 const (
-	ErrCodeValidationFailed   = "VALIDATION_FAILED"
-	ErrCodeInvalidRequest     = "INVALID_REQUEST"
-	ErrCodeRecaptchaFailed    = "RECAPTCHA_FAILED"
-	ErrCodeInternalError      = "INTERNAL_ERROR"
-	ErrCodeRateLimitExceeded  = "RATE_LIMIT_EXCEEDED"
-	ErrCodeUnauthorized       = "UNAUTHORIZED"
-	ErrCodeForbidden          = "FORBIDDEN"
+	ErrCodeValidationFailed  = "VALIDATION_FAILED"
+	ErrCodeInvalidRequest    = "INVALID_REQUEST"
+	ErrCodeRecaptchaFailed   = "RECAPTCHA_FAILED"
+	ErrCodeInternalError     = "INTERNAL_ERROR"
+	ErrCodeRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+	ErrCodeUnauthorized      = "UNAUTHORIZED"
+	ErrCodeForbidden         = "FORBIDDEN"
+	ErrCodeCircuitOpen       = "UPSTREAM_CIRCUIT_OPEN"
+	ErrCodeOutboundQueueFull = "OUTBOUND_QUEUE_FULL"
+	ErrCodeOutboundTimeout   = "OUTBOUND_QUEUE_TIMEOUT"
+	ErrCodeRequestCanceled   = "REQUEST_CANCELED"
+)
+
+// Error codes for tokens rejected by the provider (strict mode)
+const (
+	ErrCodeTokenInvalid      = "TOKEN_INVALID"
+	ErrCodeTokenMalformed    = "TOKEN_MALFORMED"
+	ErrCodeTokenMissing      = "TOKEN_MISSING"
+	ErrCodeTokenExpired      = "TOKEN_EXPIRED"
+	ErrCodeTokenDuplicate    = "TOKEN_DUPLICATE"
+	ErrCodeTokenSiteMismatch = "TOKEN_SITE_MISMATCH"
+	ErrCodeTokenBrowserError = "TOKEN_BROWSER_ERROR"
+	ErrCodeActionMismatch    = "ACTION_MISMATCH"
+	ErrCodeTokenStale        = "TOKEN_STALE"
+	ErrCodeHostnameMismatch  = "HOSTNAME_MISMATCH"
+	ErrCodeAppMismatch       = "APP_MISMATCH"
 )
 
 // Predefined errors
@@ -62,3 +81,53 @@ func NewInternalError(message string, internal error) *AppError {
 		Internal:   internal,
 	}
 }
+
+// NewForbiddenError signals a request the authenticated caller is not allowed to make.
+func NewForbiddenError(message string, internal error) *AppError {
+	return &AppError{
+		Code:       ErrCodeForbidden,
+		Message:    message,
+		HTTPStatus: 403,
+		Internal:   internal,
+	}
+}
+
+// NewCircuitOpenError signals that the upstream call was skipped because its circuit breaker is open.
+func NewCircuitOpenError(message string, internal error) *AppError {
+	return &AppError{
+		Code:       ErrCodeCircuitOpen,
+		Message:    message,
+		HTTPStatus: 503,
+		Internal:   internal,
+	}
+}
+
+// NewOutboundShedError signals that the upstream call was dropped by the outbound limiter, with
+// ErrCodeOutboundQueueFull or ErrCodeOutboundTimeout.
+func NewOutboundShedError(code, message string) *AppError {
+	return &AppError{
+		Code:       code,
+		Message:    message,
+		HTTPStatus: 503,
+	}
+}
+
+// NewRequestCanceledError signals that the caller went away or ran out of time before the
+// verification completed. It says nothing about the provider's health.
+func NewRequestCanceledError(internal error) *AppError {
+	return &AppError{
+		Code:       ErrCodeRequestCanceled,
+		Message:    "request canceled before the verification completed",
+		HTTPStatus: 408,
+		Internal:   internal,
+	}
+}
+
+// NewTokenError reports a token the provider rejected, with one of the ErrCodeToken* codes.
+func NewTokenError(code, message string, httpStatus int) *AppError {
+	return &AppError{
+		Code:       code,
+		Message:    message,
+		HTTPStatus: httpStatus,
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/service"
+)
+
+type annotateRequest struct {
+	Annotation service.Annotation         `json:"annotation"`
+	Reasons    []service.AnnotationReason `json:"reasons"`
+	Site       string                     `json:"site"`
+}
+
+// AnnotateHandler reports the real outcome of past assessments to the provider.
+type AnnotateHandler struct {
+	annotator service.Annotator
+}
+
+// NewAnnotateHandler wires the annotator into an AnnotateHandler instance.
+func NewAnnotateHandler(annotator service.Annotator) AnnotateHandler {
+	return AnnotateHandler{annotator: annotator}
+}
+
+// Handle forwards the annotation for the assessment identified by the :id path parameter.
+func (h AnnotateHandler) Handle(c *gin.Context) {
+	var payload annotateRequest
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	assessmentID := c.Param("id")
+	ctx := siteContext(c.Request.Context(), c, payload.Site, "")
+	if err := h.annotator.Annotate(ctx, assessmentID, payload.Annotation, payload.Reasons); err != nil {
+		writeAssessError(c, err)
+		return
+	}
+
+	logger.Log.Info("assessment annotated",
+		"assessment_id", assessmentID,
+		"annotation", payload.Annotation,
+		"reasons", payload.Reasons,
+		"ip", c.ClientIP(),
+	)
+
+	c.Status(http.StatusNoContent)
+}
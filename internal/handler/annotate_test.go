@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/service"
+)
+
+type mockAnnotator struct {
+	assessmentID string
+	annotation   service.Annotation
+	err          error
+}
+
+func (m *mockAnnotator) Annotate(ctx context.Context, assessmentID string, annotation service.Annotation, reasons []service.AnnotationReason) error {
+	m.assessmentID = assessmentID
+	m.annotation = annotation
+	return m.err
+}
+
+func TestAnnotateHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"annotated", nil, http.StatusNoContent},
+		{"invalid annotation", apperrors.NewValidationError("unknown annotation", nil), http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotator := &mockAnnotator{err: tc.err}
+
+			router := gin.New()
+			router.POST("/assessments/:id/annotate", NewAnnotateHandler(annotator).Handle)
+
+			body, _ := json.Marshal(annotateRequest{
+				Annotation: service.AnnotationFraudulent,
+				Reasons:    []service.AnnotationReason{service.AnnotationReasonChargeback},
+			})
+			req, _ := http.NewRequest(http.MethodPost, "/assessments/abc123/annotate", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if annotator.assessmentID != "abc123" || annotator.annotation != service.AnnotationFraudulent {
+				t.Errorf("unexpected annotation forwarded: %+v", annotator)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/policy"
+	"api-recaptcha/internal/service"
+)
+
+type captchaVerifyRequest struct {
+	Token     string `json:"token" binding:"required"`
+	Action    string `json:"action"`
+	Provider  string `json:"provider"`
+	SiteKey   string `json:"siteKey"`
+	AccountID string `json:"accountId"`
+	clientSignals
+}
+
+// CaptchaHandler verifies tokens from any registered CAPTCHA vendor.
+type CaptchaHandler struct {
+	verification
+	providers *service.ProviderRegistry
+}
+
+// NewCaptchaHandler wires the provider registry into a CaptchaHandler instance.
+// A nil policy engine falls back to the built-in thresholds.
+func NewCaptchaHandler(providers *service.ProviderRegistry, policies *policy.Engine, opts ...VerifyOption) CaptchaHandler {
+	return CaptchaHandler{
+		verification: newVerification(policies, opts),
+		providers:    providers,
+	}
+}
+
+// Handle resolves the provider from the request (explicit name or site key) and verifies the token with it.
+func (h CaptchaHandler) Handle(c *gin.Context) {
+	var payload captchaVerifyRequest
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	provider, assessor, err := h.providers.Resolve(payload.Provider, payload.SiteKey)
+	if err != nil {
+		writeAssessError(c, err)
+		return
+	}
+
+	logger.Log.Debug("captcha provider resolved",
+		"provider", provider,
+		"action", payload.Action,
+	)
+
+	ctx := h.eventContext(c, service.EventDetails{AccountID: payload.AccountID}, payload.clientSignals)
+	ctx = siteContext(ctx, c, "", payload.SiteKey)
+
+	assessment, err := assessor.Assess(ctx, payload.Token, payload.Action)
+	if err != nil {
+		h.writeAssessFailure(c, payload.Action, err)
+		return
+	}
+
+	h.writeDecision(c, payload.Action, assessment)
+}
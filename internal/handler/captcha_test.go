@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/service"
+)
+
+func newTestRegistry() *service.ProviderRegistry {
+	registry := service.NewProviderRegistry(service.ProviderEnterprise)
+	for _, name := range []string{service.ProviderEnterprise, service.ProviderHCaptcha, service.ProviderTurnstile} {
+		provider := name
+		registry.Register(provider, &mockAssessor{
+			assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+				return service.AssessmentResult{Valid: true, Score: 0.9, Action: provider}, nil
+			},
+		}, provider+"-site-key")
+	}
+	return registry
+}
+
+func TestCaptchaHandler_Handle_ResolvesProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewCaptchaHandler(newTestRegistry(), nil)
+
+	router := gin.New()
+	router.POST("/captcha/verify", handler.Handle)
+
+	testCases := []struct {
+		name     string
+		request  captchaVerifyRequest
+		expected string
+	}{
+		{"default provider", captchaVerifyRequest{Token: "token"}, service.ProviderEnterprise},
+		{"explicit provider", captchaVerifyRequest{Token: "token", Provider: "hcaptcha"}, service.ProviderHCaptcha},
+		{"provider from site key", captchaVerifyRequest{Token: "token", SiteKey: "turnstile-site-key"}, service.ProviderTurnstile},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.request)
+			req, _ := http.NewRequest(http.MethodPost, "/captcha/verify", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+
+			var result verifyResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+
+			// The mock echoes its provider name in the action field.
+			if result.Action != tc.expected {
+				t.Errorf("expected provider %s, got %s", tc.expected, result.Action)
+			}
+		})
+	}
+}
+
+func TestCaptchaHandler_Handle_UnknownProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewCaptchaHandler(newTestRegistry(), nil)
+
+	router := gin.New()
+	router.POST("/captcha/verify", handler.Handle)
+
+	testCases := []captchaVerifyRequest{
+		{Token: "token", Provider: "unknown"},
+		{Token: "token", SiteKey: "unknown-site-key"},
+		{Token: "token", Provider: "hcaptcha", SiteKey: "turnstile-site-key"},
+	}
+
+	for _, request := range testCases {
+		body, _ := json.Marshal(request)
+		req, _ := http.NewRequest(http.MethodPost, "/captcha/verify", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %+v, got %d", request, w.Code)
+		}
+
+		var errResp errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+			t.Fatalf("failed to unmarshal error response: %v", err)
+		}
+		if errResp.Code != apperrors.ErrCodeValidationFailed {
+			t.Errorf("expected error code %s, got %s", apperrors.ErrCodeValidationFailed, errResp.Code)
+		}
+	}
+}
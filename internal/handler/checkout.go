@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/policy"
+	"api-recaptcha/internal/service"
+)
+
+// defaultCheckoutAction is the action verified when a checkout request does not name one.
+const defaultCheckoutAction = "checkout"
+
+type checkoutRequest struct {
+	Token       string                   `json:"token" binding:"required"`
+	Action      string                   `json:"action"`
+	AccountID   string                   `json:"accountId"`
+	Site        string                   `json:"site"`
+	Transaction *service.TransactionData `json:"transaction" binding:"required"`
+	clientSignals
+}
+
+// CheckoutHandler verifies tokens of payment flows, forwarding the transaction for a fraud
+// prevention assessment.
+type CheckoutHandler struct {
+	verification
+	recaptcha service.Assessor
+}
+
+// NewCheckoutHandler wires the dependencies into a CheckoutHandler instance.
+// A nil policy engine falls back to the built-in thresholds.
+func NewCheckoutHandler(recaptcha service.Assessor, policies *policy.Engine, opts ...VerifyOption) CheckoutHandler {
+	return CheckoutHandler{
+		verification: newVerification(policies, opts),
+		recaptcha:    recaptcha,
+	}
+}
+
+// Handle verifies the token with the transaction attached and returns the fraud verdicts
+// alongside the standard result.
+func (h CheckoutHandler) Handle(c *gin.Context) {
+	var payload checkoutRequest
+	if !bindJSON(c, &payload) {
+		return
+	}
+	// Rejected before the token is presented, so a corrected retry can still use it.
+	if err := payload.Transaction.Validate(); err != nil {
+		writeAssessError(c, err)
+		return
+	}
+
+	action := payload.Action
+	if action == "" {
+		action = defaultCheckoutAction
+	}
+
+	ctx := h.eventContext(c, service.EventDetails{
+		AccountID:   payload.AccountID,
+		Transaction: payload.Transaction,
+	}, payload.clientSignals)
+	ctx = siteContext(ctx, c, payload.Site, "")
+
+	assessment, err := h.recaptcha.Assess(ctx, payload.Token, action)
+	if err != nil {
+		h.writeAssessFailure(c, action, err)
+		return
+	}
+
+	h.writeDecision(c, action, assessment)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/service"
+)
+
+func TestCheckoutHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotAction string
+	calls := 0
+	mock := &mockAssessor{
+		assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+			calls++
+			gotAction = action
+			return service.AssessmentResult{
+				Valid:  true,
+				Score:  0.9,
+				Action: action,
+				FraudPrevention: &service.FraudPreventionAssessment{
+					TransactionRisk: 0.7,
+					CardTestingRisk: 0.2,
+				},
+			}, nil
+		},
+	}
+
+	router := gin.New()
+	router.POST("/checkout", NewCheckoutHandler(mock, nil).Handle)
+
+	testCases := []struct {
+		name           string
+		request        any
+		expectedStatus int
+	}{
+		{"with transaction", checkoutRequest{
+			Token:       "token",
+			Transaction: &service.TransactionData{Value: 42.5, CurrencyCode: "EUR"},
+		}, http.StatusOK},
+		{"missing transaction", map[string]string{"token": "token"}, http.StatusBadRequest},
+		{"invalid transaction", checkoutRequest{
+			Token:       "token",
+			Transaction: &service.TransactionData{Value: -1},
+		}, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls = 0
+			body, _ := json.Marshal(tc.request)
+			req, _ := http.NewRequest(http.MethodPost, "/checkout", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				if calls != 0 {
+					t.Errorf("expected rejected requests not to be assessed, got %d calls", calls)
+				}
+				return
+			}
+
+			var result verifyResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if gotAction != defaultCheckoutAction {
+				t.Errorf("expected action %q, got %q", defaultCheckoutAction, gotAction)
+			}
+			if result.FraudPrevention == nil || result.FraudPrevention.TransactionRisk != 0.7 {
+				t.Errorf("expected fraud verdicts in response, got %+v", result.FraudPrevention)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/policy"
+	"api-recaptcha/internal/service"
+)
+
+type expressRequest struct {
+	Action    string `json:"action"`
+	AccountID string `json:"accountId"`
+	Site      string `json:"site"`
+	clientSignals
+}
+
+// ExpressHandler scores backend-only traffic that cannot render a challenge, from the request
+// signals alone.
+type ExpressHandler struct {
+	verification
+	express service.ExpressAssessor
+}
+
+// NewExpressHandler wires the dependencies into an ExpressHandler instance.
+// A nil policy engine falls back to the built-in thresholds.
+func NewExpressHandler(express service.ExpressAssessor, policies *policy.Engine, opts ...VerifyOption) ExpressHandler {
+	return ExpressHandler{
+		verification: newVerification(policies, opts),
+		express:      express,
+	}
+}
+
+// Handle builds an express assessment from the client signals and returns the scored decision.
+func (h ExpressHandler) Handle(c *gin.Context) {
+	var payload expressRequest
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	ctx := h.eventContext(c, service.EventDetails{AccountID: payload.AccountID}, payload.clientSignals)
+	ctx = siteContext(ctx, c, payload.Site, "")
+
+	assessment, err := h.express.AssessExpress(ctx, payload.Action)
+	if err != nil {
+		h.writeAssessFailure(c, payload.Action, err)
+		return
+	}
+
+	h.writeDecision(c, payload.Action, assessment)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/policy"
+	"api-recaptcha/internal/service"
+)
+
+type mockExpressAssessor struct {
+	details service.EventDetails
+	score   float64
+}
+
+func (m *mockExpressAssessor) AssessExpress(ctx context.Context, action string) (service.AssessmentResult, error) {
+	m.details = service.EventDetailsFromContext(ctx)
+	return service.AssessmentResult{Valid: true, Score: m.score, Action: action}, nil
+}
+
+func TestExpressHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	express := &mockExpressAssessor{score: 0.2}
+
+	router := gin.New()
+	router.POST("/express", NewExpressHandler(express, nil, WithClientSignals(ClientSignalsConfig{Enabled: true})).Handle)
+
+	body, _ := json.Marshal(expressRequest{Action: "api_call"})
+	req, _ := http.NewRequest(http.MethodPost, "/express", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "backend-client")
+	req.RemoteAddr = "203.0.113.7:1234"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var result verifyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if result.Decision != policy.DecisionBlock {
+		t.Errorf("expected decision %s for a low score, got %s", policy.DecisionBlock, result.Decision)
+	}
+	if express.details.UserIPAddress != "203.0.113.7" || express.details.UserAgent != "backend-client" {
+		t.Errorf("expected client signals to be forwarded, got %+v", express.details)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/service"
 )
 
 var startTime = time.Now()
@@ -25,11 +27,41 @@ func HealthCheck(c *gin.Context) {
 	})
 }
 
-// ReadinessCheck checks if the service is ready to accept traffic.
-func ReadinessCheck(c *gin.Context) {
-	// In a real application, you would check database connections,
-	// external service availability, etc.
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ready",
-	})
+type readinessResponse struct {
+	Status    string                          `json:"status"`
+	Circuits  map[string]service.BreakerState `json:"circuits,omitempty"`
+	Providers []service.ProviderHealth        `json:"providers,omitempty"`
+}
+
+// ReadinessCheck checks if the service is ready to accept traffic and reports the state of
+// the upstream circuit breakers and, when failover is configured, the health of each provider
+// in the chain. An open circuit or an unhealthy provider marks the service as "degraded" but
+// keeps answering 200: every instance shares the same upstream, so pulling them out of rotation
+// would not help, and callers still get fast failures or degraded decisions.
+func ReadinessCheck(breakers map[string]*service.CircuitBreaker, failover *service.FailoverAssessor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := readinessResponse{Status: "ready"}
+
+		if len(breakers) > 0 {
+			resp.Circuits = make(map[string]service.BreakerState, len(breakers))
+			for name, breaker := range breakers {
+				state := breaker.State()
+				resp.Circuits[name] = state
+				if state != service.BreakerClosed {
+					resp.Status = "degraded"
+				}
+			}
+		}
+
+		if failover != nil {
+			resp.Providers = failover.Health()
+			for _, provider := range resp.Providers {
+				if !provider.Healthy {
+					resp.Status = "degraded"
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
 }
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/service"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -45,7 +49,35 @@ func TestReadinessCheck(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	router.GET("/ready", ReadinessCheck)
+	router.GET("/ready", ReadinessCheck(nil, nil))
+
+	req, _ := http.NewRequest(http.MethodGet, "/ready", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	var resp readinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if resp.Status != "ready" {
+		t.Errorf("expected status 'ready', got %s", resp.Status)
+	}
+}
+
+func TestReadinessCheck_OpenCircuit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	breaker := service.NewCircuitBreaker("enterprise", service.BreakerConfig{MinRequests: 1, FailureRatio: 1})
+	breaker.Allow()
+	breaker.Record(false)
+
+	router := gin.New()
+	router.GET("/ready", ReadinessCheck(map[string]*service.CircuitBreaker{"enterprise": breaker}, nil))
 
 	req, _ := http.NewRequest(http.MethodGet, "/ready", nil)
 	w := httptest.NewRecorder()
@@ -55,12 +87,51 @@ func TestReadinessCheck(t *testing.T) {
 		t.Errorf("expected status 200, got %d", w.Code)
 	}
 
-	var resp map[string]string
+	var resp readinessResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
 
-	if resp["status"] != "ready" {
-		t.Errorf("expected status 'ready', got %s", resp["status"])
+	if resp.Status != "degraded" {
+		t.Errorf("expected status 'degraded', got %s", resp.Status)
+	}
+	if resp.Circuits["enterprise"] != service.BreakerOpen {
+		t.Errorf("expected open circuit, got %s", resp.Circuits["enterprise"])
+	}
+}
+
+func TestReadinessCheck_UnhealthyProvider(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	down := &mockAssessor{
+		assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+			return service.AssessmentResult{}, apperrors.NewRecaptchaError("down", nil)
+		},
+	}
+	failover := service.NewFailoverAssessor(service.FailoverConfig{FailureThreshold: 1},
+		service.NamedAssessor{Name: service.ProviderEnterprise, Assessor: down},
+		service.NamedAssessor{Name: service.ProviderSiteVerify, Assessor: &mockAssessor{}},
+	)
+	if _, err := failover.Assess(context.Background(), "token", "login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/ready", ReadinessCheck(nil, failover))
+
+	req, _ := http.NewRequest(http.MethodGet, "/ready", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp readinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if resp.Status != "degraded" {
+		t.Errorf("expected status 'degraded', got %s", resp.Status)
+	}
+	if len(resp.Providers) != 2 || resp.Providers[0].Healthy || !resp.Providers[1].Healthy {
+		t.Errorf("unexpected provider health: %+v", resp.Providers)
 	}
 }
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/service"
+)
+
+type passwordLeakRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Site     string `json:"site"`
+}
+
+type passwordLeakResponse struct {
+	Leaked bool `json:"leaked"`
+}
+
+// PasswordLeakHandler checks credentials against Google's leak database.
+type PasswordLeakHandler struct {
+	checker service.PasswordLeakChecker
+}
+
+// NewPasswordLeakHandler wires the leak checker into a PasswordLeakHandler instance.
+func NewPasswordLeakHandler(checker service.PasswordLeakChecker) PasswordLeakHandler {
+	return PasswordLeakHandler{checker: checker}
+}
+
+// Handle receives a username and password and reports whether the pair is known to be leaked.
+// Credentials are never logged.
+func (h PasswordLeakHandler) Handle(c *gin.Context) {
+	var payload passwordLeakRequest
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	ctx := siteContext(c.Request.Context(), c, payload.Site, "")
+	leaked, err := h.checker.CheckPasswordLeak(ctx, payload.Username, payload.Password)
+	if err != nil {
+		writeAssessError(c, err)
+		return
+	}
+
+	logger.Log.Info("password leak check completed",
+		"leaked", leaked,
+		"ip", c.ClientIP(),
+	)
+
+	c.JSON(http.StatusOK, passwordLeakResponse{Leaked: leaked})
+}
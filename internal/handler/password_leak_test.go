@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockLeakChecker struct {
+	leaked bool
+}
+
+func (m mockLeakChecker) CheckPasswordLeak(ctx context.Context, username, password string) (bool, error) {
+	return m.leaked, nil
+}
+
+func TestPasswordLeakHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name           string
+		request        any
+		leaked         bool
+		expectedStatus int
+	}{
+		{"leaked credential", passwordLeakRequest{Username: "user", Password: "secret"}, true, http.StatusOK},
+		{"safe credential", passwordLeakRequest{Username: "user", Password: "secret"}, false, http.StatusOK},
+		{"missing password", map[string]string{"username": "user"}, false, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/password-leak", NewPasswordLeakHandler(mockLeakChecker{leaked: tc.leaked}).Handle)
+
+			body, _ := json.Marshal(tc.request)
+			req, _ := http.NewRequest(http.MethodPost, "/password-leak", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var result passwordLeakResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if result.Leaked != tc.leaked {
+				t.Errorf("expected leaked %v, got %v", tc.leaked, result.Leaked)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/service"
+)
+
+// ClientSignalsConfig controls which signals about the end user's client are forwarded with
+// assessments. Disabled, nothing is forwarded, not even signals sent explicitly by the caller.
+type ClientSignalsConfig struct {
+	Enabled bool
+	// JA3Header names the header where the TLS-terminating proxy puts the client's JA3 fingerprint.
+	JA3Header string
+	// Headers lists the incoming request headers forwarded to the provider.
+	Headers []string
+}
+
+// WithClientSignals forwards the client IP address, user agent, JA3 fingerprint and selected
+// headers of each request to the provider.
+func WithClientSignals(config ClientSignalsConfig) VerifyOption {
+	return func(v *verification) {
+		v.signals = config
+	}
+}
+
+// clientSignals lets server-to-server callers pass the end user's signals explicitly,
+// since the incoming request then comes from their backend.
+type clientSignals struct {
+	UserIPAddress string   `json:"userIpAddress"`
+	UserAgent     string   `json:"userAgent"`
+	JA3           string   `json:"ja3"`
+	Headers       []string `json:"headers"`
+}
+
+// eventContext attaches details to the request context, completed with the client signals when
+// they are enabled. Explicit signals take precedence over the ones read from the request.
+func (v verification) eventContext(c *gin.Context, details service.EventDetails, explicit clientSignals) context.Context {
+	if v.signals.Enabled {
+		details.UserIPAddress = firstNonEmpty(explicit.UserIPAddress, c.ClientIP())
+		details.UserAgent = firstNonEmpty(explicit.UserAgent, c.Request.UserAgent())
+		details.JA3 = explicit.JA3
+		if details.JA3 == "" && v.signals.JA3Header != "" {
+			details.JA3 = c.GetHeader(v.signals.JA3Header)
+		}
+		details.Headers = explicit.Headers
+		if len(details.Headers) == 0 {
+			details.Headers = selectHeaders(c.Request.Header, v.signals.Headers)
+		}
+	}
+
+	return service.ContextWithEventDetails(c.Request.Context(), details)
+}
+
+// selectHeaders formats the listed headers as "Name: value" lines.
+func selectHeaders(header http.Header, names []string) []string {
+	var lines []string
+	for _, name := range names {
+		for _, value := range header.Values(name) {
+			lines = append(lines, http.CanonicalHeaderKey(name)+": "+value)
+		}
+	}
+	return lines
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/service"
+)
+
+func TestVerifyHandler_ClientSignals(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := ClientSignalsConfig{
+		Enabled:   true,
+		JA3Header: "X-JA3-Fingerprint",
+		Headers:   []string{"Accept-Language"},
+	}
+
+	testCases := []struct {
+		name     string
+		config   ClientSignalsConfig
+		request  verifyRequest
+		expected service.EventDetails
+	}{
+		{
+			name:    "collected from the request",
+			config:  config,
+			request: verifyRequest{Token: "token"},
+			expected: service.EventDetails{
+				UserIPAddress: "203.0.113.7",
+				UserAgent:     "test-agent",
+				JA3:           "ja3-hash",
+				Headers:       []string{"Accept-Language: es-ES"},
+			},
+		},
+		{
+			name:   "explicit fields take precedence",
+			config: config,
+			request: verifyRequest{Token: "token", clientSignals: clientSignals{
+				UserIPAddress: "198.51.100.1",
+				UserAgent:     "browser-agent",
+			}},
+			expected: service.EventDetails{
+				UserIPAddress: "198.51.100.1",
+				UserAgent:     "browser-agent",
+				JA3:           "ja3-hash",
+				Headers:       []string{"Accept-Language: es-ES"},
+			},
+		},
+		{
+			name:   "disabled forwards nothing",
+			config: ClientSignalsConfig{},
+			request: verifyRequest{Token: "token", clientSignals: clientSignals{
+				UserIPAddress: "198.51.100.1",
+			}},
+			expected: service.EventDetails{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got service.EventDetails
+			mock := &mockAssessor{
+				assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+					got = service.EventDetailsFromContext(ctx)
+					return service.AssessmentResult{Valid: true, Score: 0.9}, nil
+				},
+			}
+
+			router := gin.New()
+			router.POST("/verify", NewVerifyHandler(mock, nil, WithClientSignals(tc.config)).Handle)
+
+			body, _ := json.Marshal(tc.request)
+			req, _ := http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set("X-JA3-Fingerprint", "ja3-hash")
+			req.Header.Set("Accept-Language", "es-ES")
+			req.Header.Set("X-API-Key", "secret")
+			req.RemoteAddr = "203.0.113.7:1234"
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected details %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
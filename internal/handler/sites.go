@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/service"
+)
+
+// apiKeyHeader is the header carrying the app API key, checked by middleware.APIKeyAuth.
+const apiKeyHeader = "X-API-Key"
+
+// siteContext attaches what the request says about its site (explicit name, site key, Origin
+// header and app API key) so a service.SiteRegistry can route it.
+func siteContext(ctx context.Context, c *gin.Context, site, siteKey string) context.Context {
+	return service.ContextWithSite(ctx, service.SiteSelector{
+		Name:    site,
+		SiteKey: siteKey,
+		Origin:  c.GetHeader("Origin"),
+		APIKey:  c.GetHeader(apiKeyHeader),
+	})
+}
@@ -7,12 +7,24 @@ import (
 
 	apperrors "api-recaptcha/internal/errors"
 	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/metrics"
+	"api-recaptcha/internal/policy"
 	"api-recaptcha/internal/service"
+	"api-recaptcha/internal/tenant"
 )
 
 type verifyRequest struct {
-	Token  string `json:"token" binding:"required"`
-	Action string `json:"action"`
+	Token     string `json:"token" binding:"required"`
+	Action    string `json:"action"`
+	AccountID string `json:"accountId"`
+	Site      string `json:"site"`
+	clientSignals
+}
+
+// verifyResponse extends the assessment with the decision taken by the policy engine.
+type verifyResponse struct {
+	service.AssessmentResult
+	policy.Outcome
 }
 
 type errorResponse struct {
@@ -20,20 +32,82 @@ type errorResponse struct {
 	Code  string `json:"code,omitempty"`
 }
 
+// verification holds the settings shared by the token verification handlers.
+type verification struct {
+	policies *policy.Engine
+	strict   bool
+	signals  ClientSignalsConfig
+}
+
+// VerifyOption customizes the verification handlers.
+type VerifyOption func(*verification)
+
+// WithStrictMode makes invalid tokens answer with a 4xx error carrying a TOKEN_* code
+// instead of a 200 response with valid=false.
+func WithStrictMode(enabled bool) VerifyOption {
+	return func(v *verification) {
+		v.strict = enabled
+	}
+}
+
+// newVerification applies the options. A nil policy engine falls back to the built-in thresholds.
+func newVerification(policies *policy.Engine, opts []VerifyOption) verification {
+	if policies == nil {
+		policies = policy.NewDefaultEngine()
+	}
+	v := verification{policies: policies}
+	for _, opt := range opts {
+		opt(&v)
+	}
+	return v
+}
+
+// policiesFor returns the policies of the request's tenant, or the handler's policies when the
+// request has no tenant or the tenant has no policies of its own.
+func (v verification) policiesFor(c *gin.Context) *policy.Engine {
+	if t, ok := tenant.FromGinContext(c); ok && t.Policies != nil {
+		return t.Policies
+	}
+	return v.policies
+}
+
 // VerifyHandler processes the verification requests coming from the client.
 type VerifyHandler struct {
+	verification
 	recaptcha service.Assessor
 }
 
 // NewVerifyHandler wires the dependencies into a VerifyHandler instance.
-func NewVerifyHandler(recaptcha service.Assessor) VerifyHandler {
-	return VerifyHandler{recaptcha: recaptcha}
+// A nil policy engine falls back to the built-in thresholds.
+func NewVerifyHandler(recaptcha service.Assessor, policies *policy.Engine, opts ...VerifyOption) VerifyHandler {
+	return VerifyHandler{
+		verification: newVerification(policies, opts),
+		recaptcha:    recaptcha,
+	}
 }
 
 // Handle receives a token and delegates the validation to the reCAPTCHA Enterprise API.
 func (h VerifyHandler) Handle(c *gin.Context) {
 	var payload verifyRequest
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	if !bindJSON(c, &payload) {
+		return
+	}
+
+	ctx := h.eventContext(c, service.EventDetails{AccountID: payload.AccountID}, payload.clientSignals)
+	ctx = siteContext(ctx, c, payload.Site, "")
+
+	assessment, err := h.recaptcha.Assess(ctx, payload.Token, payload.Action)
+	if err != nil {
+		h.writeAssessFailure(c, payload.Action, err)
+		return
+	}
+
+	h.writeDecision(c, payload.Action, assessment)
+}
+
+// bindJSON decodes the request body into payload and answers 400 when it is not valid.
+func bindJSON(c *gin.Context, payload any) bool {
+	if err := c.ShouldBindJSON(payload); err != nil {
 		logger.Log.Warn("invalid request body",
 			"error", err.Error(),
 			"ip", c.ClientIP(),
@@ -42,44 +116,107 @@ func (h VerifyHandler) Handle(c *gin.Context) {
 			Error: "invalid request body",
 			Code:  apperrors.ErrCodeInvalidRequest,
 		})
+		return false
+	}
+	return true
+}
+
+// writeAssessError maps an assessment error into the JSON error response.
+func writeAssessError(c *gin.Context, err error) {
+	// Check if it's an AppError
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		logger.Log.Error("recaptcha verification failed",
+			"error", appErr.Internal,
+			"message", appErr.Message,
+			"code", appErr.Code,
+			"ip", c.ClientIP(),
+		)
+		c.JSON(appErr.HTTPStatus, errorResponse{
+			Error: appErr.UserMessage(),
+			Code:  appErr.Code,
+		})
 		return
 	}
 
-	assessment, err := h.recaptcha.Assess(c.Request.Context(), payload.Token, payload.Action)
-	if err != nil {
-		// Check if it's an AppError
-		if appErr, ok := err.(*apperrors.AppError); ok {
-			logger.Log.Error("recaptcha verification failed",
-				"error", appErr.Internal,
-				"message", appErr.Message,
-				"code", appErr.Code,
-				"ip", c.ClientIP(),
-			)
-			c.JSON(appErr.HTTPStatus, errorResponse{
-				Error: appErr.UserMessage(),
-				Code:  appErr.Code,
-			})
-			return
-		}
-
-		// Fallback for unexpected errors
-		logger.Log.Error("unexpected error during recaptcha verification",
-			"error", err.Error(),
+	// Fallback for unexpected errors
+	logger.Log.Error("unexpected error during recaptcha verification",
+		"error", err.Error(),
+		"ip", c.ClientIP(),
+	)
+	c.JSON(http.StatusInternalServerError, errorResponse{
+		Error: "internal server error",
+		Code:  apperrors.ErrCodeInternalError,
+	})
+}
+
+// writeAssessFailure answers with the degraded outcome configured for the action when the
+// provider is unavailable, and with the error itself otherwise (fail-closed).
+func (v verification) writeAssessFailure(c *gin.Context, action string, err error) {
+	if !service.IsUpstreamFailure(err) {
+		writeAssessError(c, err)
+		return
+	}
+
+	outcome, ok := v.policiesFor(c).Degrade(action)
+	if !ok {
+		writeAssessError(c, err)
+		return
+	}
+
+	metrics.Inc(metrics.DegradedVerifications, metrics.Action(action), string(outcome.Decision))
+	logger.Log.Warn("verification provider unavailable, returning degraded decision",
+		"error", err.Error(),
+		"action", action,
+		"decision", outcome.Decision,
+		"rule", outcome.Rule,
+		"ip", c.ClientIP(),
+	)
+
+	c.JSON(http.StatusOK, verifyResponse{
+		AssessmentResult: service.AssessmentResult{Action: action, Degraded: true},
+		Outcome:          outcome,
+	})
+}
+
+// writeDecision evaluates the assessment against the policies and writes the response.
+// In strict mode an invalid token is answered with the error matching its invalid reason.
+func (v verification) writeDecision(c *gin.Context, action string, assessment service.AssessmentResult) {
+	policies := v.policiesFor(c)
+	assessment = policies.EnforceAction(action, assessment)
+
+	if v.strict && !assessment.Valid {
+		logger.Log.Info("recaptcha token rejected",
+			"action", action,
+			"invalid_reason", assessment.InvalidReason,
+			"provider", assessment.Provider,
 			"ip", c.ClientIP(),
 		)
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Error: "internal server error",
-			Code:  apperrors.ErrCodeInternalError,
+		appErr := assessment.InvalidReason.AppError()
+		c.JSON(appErr.HTTPStatus, errorResponse{
+			Error: appErr.UserMessage(),
+			Code:  appErr.Code,
 		})
 		return
 	}
 
+	outcome := policies.Evaluate(action, assessment)
+
 	logger.Log.Info("recaptcha verification successful",
-		"action", payload.Action,
+		"action", action,
 		"valid", assessment.Valid,
+		"invalid_reason", assessment.InvalidReason,
 		"score", assessment.Score,
+		"provider", assessment.Provider,
+		"site", assessment.Site,
+		"cached", assessment.Cached,
+		"account_defender_labels", assessment.AccountDefenderLabels,
+		"decision", outcome.Decision,
+		"rule", outcome.Rule,
 		"ip", c.ClientIP(),
 	)
 
-	c.JSON(http.StatusOK, assessment)
+	c.JSON(http.StatusOK, verifyResponse{
+		AssessmentResult: assessment,
+		Outcome:          outcome,
+	})
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/policy"
 	"api-recaptcha/internal/service"
+	"api-recaptcha/internal/tenant"
 )
 
 // mockAssessor implements the service.Assessor interface for testing
@@ -39,7 +41,7 @@ func TestVerifyHandler_Handle_Success(t *testing.T) {
 		},
 	}
 
-	handler := NewVerifyHandler(mock)
+	handler := NewVerifyHandler(mock, nil)
 
 	router := gin.New()
 	router.POST("/verify", handler.Handle)
@@ -60,7 +62,7 @@ func TestVerifyHandler_Handle_Success(t *testing.T) {
 		t.Errorf("expected status 200, got %d", w.Code)
 	}
 
-	var result service.AssessmentResult
+	var result verifyResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
@@ -71,13 +73,64 @@ func TestVerifyHandler_Handle_Success(t *testing.T) {
 	if result.Score != 0.9 {
 		t.Errorf("expected score 0.9, got %f", result.Score)
 	}
+	if result.Decision != policy.DecisionAllow {
+		t.Errorf("expected decision %s, got %s", policy.DecisionAllow, result.Decision)
+	}
+	if result.Rule != policy.DefaultRuleName {
+		t.Errorf("expected rule %s, got %s", policy.DefaultRuleName, result.Rule)
+	}
+}
+
+func TestVerifyHandler_Handle_PolicyDecision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mock := &mockAssessor{
+		assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+			return service.AssessmentResult{Valid: true, Score: 0.6, Action: action}, nil
+		},
+	}
+
+	policies, err := policy.NewEngine(policy.Config{
+		Rules: []policy.Rule{{Action: "checkout", AllowScore: 0.8, ChallengeScore: 0.5}},
+	})
+	if err != nil {
+		t.Fatalf("failed to build policy engine: %v", err)
+	}
+
+	handler := NewVerifyHandler(mock, policies)
+
+	router := gin.New()
+	router.POST("/verify", handler.Handle)
+
+	body, _ := json.Marshal(verifyRequest{Token: "valid-token", Action: "checkout"})
+	req, _ := http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var result verifyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if result.Decision != policy.DecisionChallenge {
+		t.Errorf("expected decision %s, got %s", policy.DecisionChallenge, result.Decision)
+	}
+	if result.Rule != "checkout" {
+		t.Errorf("expected rule checkout, got %s", result.Rule)
+	}
 }
 
 func TestVerifyHandler_Handle_InvalidRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mock := &mockAssessor{}
-	handler := NewVerifyHandler(mock)
+	handler := NewVerifyHandler(mock, nil)
 
 	router := gin.New()
 	router.POST("/verify", handler.Handle)
@@ -106,7 +159,7 @@ func TestVerifyHandler_Handle_RecaptchaError(t *testing.T) {
 		},
 	}
 
-	handler := NewVerifyHandler(mock)
+	handler := NewVerifyHandler(mock, nil)
 
 	router := gin.New()
 	router.POST("/verify", handler.Handle)
@@ -136,3 +189,207 @@ func TestVerifyHandler_Handle_RecaptchaError(t *testing.T) {
 		t.Errorf("expected error code %s, got %s", apperrors.ErrCodeRecaptchaFailed, errResp.Code)
 	}
 }
+
+func TestVerifyHandler_Handle_DegradedOnUpstreamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mock := &mockAssessor{
+		assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+			return service.AssessmentResult{}, apperrors.NewRecaptchaError("recaptcha failed", nil)
+		},
+	}
+
+	policies, err := policy.NewEngine(policy.Config{
+		Rules: []policy.Rule{
+			{Action: "newsletter", AllowScore: 0.5, ChallengeScore: 0.3, OnUpstreamError: policy.DecisionAllow},
+			{Action: "payment", AllowScore: 0.8, ChallengeScore: 0.6},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build policy engine: %v", err)
+	}
+
+	handler := NewVerifyHandler(mock, policies)
+
+	router := gin.New()
+	router.POST("/verify", handler.Handle)
+
+	// Fail-open action: synthesized degraded result with the configured decision.
+	body, _ := json.Marshal(verifyRequest{Token: "token", Action: "newsletter"})
+	req, _ := http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var result verifyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !result.Degraded || result.Decision != policy.DecisionAllow {
+		t.Errorf("expected degraded allow decision, got %+v", result)
+	}
+
+	// Fail-closed action: the upstream error is returned.
+	body, _ = json.Marshal(verifyRequest{Token: "token", Action: "payment"})
+	req, _ = http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", w.Code)
+	}
+}
+
+func TestVerifyHandler_Handle_StrictMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		reason service.InvalidReason
+		status int
+		code   string
+	}{
+		{service.InvalidReasonExpired, http.StatusForbidden, apperrors.ErrCodeTokenExpired},
+		{service.InvalidReasonDupe, http.StatusConflict, apperrors.ErrCodeTokenDuplicate},
+		{service.InvalidReasonMalformed, http.StatusBadRequest, apperrors.ErrCodeTokenMalformed},
+		{service.InvalidReasonUnknown, http.StatusForbidden, apperrors.ErrCodeTokenInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.reason), func(t *testing.T) {
+			mock := &mockAssessor{
+				assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+					return service.AssessmentResult{Valid: false, InvalidReason: tc.reason}, nil
+				},
+			}
+
+			router := gin.New()
+			router.POST("/verify", NewVerifyHandler(mock, nil, WithStrictMode(true)).Handle)
+			router.POST("/lenient", NewVerifyHandler(mock, nil).Handle)
+
+			body, _ := json.Marshal(verifyRequest{Token: "token", Action: "login"})
+
+			req, _ := http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, w.Code)
+			}
+
+			var errResp errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+				t.Fatalf("failed to unmarshal error response: %v", err)
+			}
+			if errResp.Code != tc.code {
+				t.Errorf("expected error code %s, got %s", tc.code, errResp.Code)
+			}
+
+			// Without strict mode the same token is reported in a 200 response.
+			req, _ = http.NewRequest(http.MethodPost, "/lenient", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status 200 without strict mode, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestVerifyHandler_Handle_ActionMismatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mock := &mockAssessor{
+		assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+			return service.AssessmentResult{Valid: true, Score: 0.9, Action: "newsletter"}, nil
+		},
+	}
+
+	router := gin.New()
+	router.POST("/verify", NewVerifyHandler(mock, nil).Handle)
+
+	body, _ := json.Marshal(verifyRequest{Token: "token", Action: "login"})
+	req, _ := http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var result verifyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if result.Valid || result.InvalidReason != service.InvalidReasonActionMismatch {
+		t.Errorf("expected ACTION_MISMATCH, got valid=%v reason=%s", result.Valid, result.InvalidReason)
+	}
+	if result.Decision != policy.DecisionBlock {
+		t.Errorf("expected decision %s, got %s", policy.DecisionBlock, result.Decision)
+	}
+}
+
+func TestVerifyHandler_Handle_TenantPolicies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mock := &mockAssessor{
+		assessFunc: func(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+			return service.AssessmentResult{Valid: true, Score: 0.6, Action: action}, nil
+		},
+	}
+
+	strict, err := policy.NewEngine(policy.Config{Rules: []policy.Rule{{Action: "login", AllowScore: 0.9, ChallengeScore: 0.7}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler := NewVerifyHandler(mock, nil)
+
+	testCases := []struct {
+		name     string
+		tenant   *tenant.Tenant
+		expected policy.Decision
+	}{
+		{"no tenant", nil, policy.DecisionAllow},
+		{"tenant without policies", &tenant.Tenant{ID: "plain"}, policy.DecisionAllow},
+		{"tenant policies", &tenant.Tenant{ID: "strict", Policies: strict}, policy.DecisionBlock},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/verify", func(c *gin.Context) {
+				if tc.tenant != nil {
+					tenant.Attach(c, tc.tenant)
+				}
+				handler.Handle(c)
+			})
+
+			body, _ := json.Marshal(verifyRequest{Token: "token", Action: "login"})
+			req, _ := http.NewRequest(http.MethodPost, "/verify", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var result verifyResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if result.Decision != tc.expected {
+				t.Errorf("expected decision %s, got %s", tc.expected, result.Decision)
+			}
+		})
+	}
+}
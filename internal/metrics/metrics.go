@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// OtherAction is the label of action-keyed counters for actions that were not registered.
+const OtherAction = "other"
+
+// knownActions are the actions reported by name. Actions come from requests, and expvar maps
+// never shrink, so any other action is folded into OtherAction.
+var (
+	actionsMu    sync.RWMutex
+	knownActions = make(map[string]bool)
+)
+
+// RegisterActions makes action-keyed counters report the given actions by name.
+func RegisterActions(actions ...string) {
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+	for _, action := range actions {
+		knownActions[action] = true
+	}
+}
+
+// Action returns the label counting action: the action itself when it was registered or
+// empty, OtherAction otherwise.
+func Action(action string) string {
+	if action == "" {
+		return ""
+	}
+	actionsMu.RLock()
+	defer actionsMu.RUnlock()
+	if knownActions[action] {
+		return action
+	}
+	return OtherAction
+}
+
+// published holds the names of the maps served by Handler, in registration order.
+var published []string
+
+// newMap publishes a counter map through expvar and registers it for Handler.
+func newMap(name string) *expvar.Map {
+	published = append(published, name)
+	return expvar.NewMap(name)
+}
+
+// Counters published through expvar. Map keys are documented next to each variable;
+// "action" is the label returned by Action.
+var (
+	// DegradedVerifications counts results synthesized because the provider was unavailable, keyed by "action:decision".
+	DegradedVerifications = newMap("degraded_verifications")
+	// ReplayedTokens counts tokens rejected because they were already used, keyed by action.
+	ReplayedTokens = newMap("replayed_tokens")
+	// CoalescedAssessments counts verifications served by a concurrent identical call, keyed by action.
+	CoalescedAssessments = newMap("coalesced_assessments")
+	// CachedAssessments counts verifications served from the result cache, keyed by action.
+	CachedAssessments = newMap("cached_assessments")
+	// HedgedRequests counts hedged upstream calls: "fired" when a second call was sent, then
+	// "won" when it answered first or "lost" when the original call did; "skipped" when the
+	// outbound limiter had no quota for the second call.
+	HedgedRequests = newMap("hedged_requests")
+	// OutboundShed counts upstream calls dropped by the outbound limiter, keyed by "queue_full" or "deadline".
+	OutboundShed = newMap("outbound_shed")
+)
+
+// Handler serves the counters of this package as JSON. Unlike expvar.Handler it leaves out
+// the runtime variables (cmdline, memstats), which say more about the host than the service.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var b strings.Builder
+		b.WriteString("{")
+		for i, name := range published {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, "\n%q: %s", name, expvar.Get(name).String())
+		}
+		b.WriteString("\n}\n")
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = io.WriteString(w, b.String())
+	})
+}
+
+// Inc increments the counter identified by labels in m. Empty labels are reported as "none".
+func Inc(m *expvar.Map, labels ...string) {
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		if label == "" {
+			label = "none"
+		}
+		parts[i] = label
+	}
+	m.Add(strings.Join(parts, ":"), 1)
+}
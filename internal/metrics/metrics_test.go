@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_ServesOnlyServiceCounters(t *testing.T) {
+	Inc(ReplayedTokens, "login")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	var body map[string]map[string]int64
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a JSON object of counter maps: %v\n%s", err, w.Body.String())
+	}
+	if len(body) != len(published) {
+		t.Errorf("expected %d maps, got %d", len(published), len(body))
+	}
+	if _, ok := body["memstats"]; ok {
+		t.Error("expected runtime variables not to be served")
+	}
+	if body["replayed_tokens"]["login"] < 1 {
+		t.Errorf("expected the replayed_tokens counter, got %v", body["replayed_tokens"])
+	}
+}
+
+func TestAction(t *testing.T) {
+	RegisterActions("login", "checkout")
+
+	tests := map[string]string{
+		"login":     "login",
+		"checkout":  "checkout",
+		"":          "",
+		"random-42": OtherAction,
+	}
+	for action, want := range tests {
+		if got := Action(action); got != want {
+			t.Errorf("Action(%q) = %q, want %q", action, got, want)
+		}
+	}
+}
@@ -5,13 +5,22 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/tenant"
 )
 
 const apiKeyHeader = "X-API-Key"
 
-// APIKeyAuth ensures that incoming requests present the expected API key before hitting the handlers.
+// APIKeyAuth ensures that incoming requests present one of the expected API keys before hitting the handlers.
 // Uses constant-time comparison to prevent timing attacks.
-func APIKeyAuth(expectedKey string) gin.HandlerFunc {
+func APIKeyAuth(expectedKeys ...string) gin.HandlerFunc {
+	return TenantAPIKeyAuth(nil, expectedKeys...)
+}
+
+// TenantAPIKeyAuth is APIKeyAuth that also accepts the API keys of the tenants in the directory.
+// The tenant owning the key is stored in the gin and request contexts (see tenant.FromGinContext),
+// and requests from a browser origin the tenant does not allow are rejected.
+func TenantAPIKeyAuth(tenants *tenant.Directory, expectedKeys ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		providedKey := c.GetHeader(apiKeyHeader)
 		if providedKey == "" {
@@ -19,8 +28,23 @@ func APIKeyAuth(expectedKey string) gin.HandlerFunc {
 			return
 		}
 
-		// Use constant-time comparison to prevent timing attacks
-		if subtle.ConstantTimeCompare([]byte(providedKey), []byte(expectedKey)) != 1 {
+		if t, ok := tenants.Authenticate(providedKey); ok {
+			if !t.AllowsOrigin(c.GetHeader("Origin")) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "origin not allowed"})
+				return
+			}
+			tenant.Attach(c, t)
+			c.Next()
+			return
+		}
+
+		// Use constant-time comparison to prevent timing attacks; every key is compared
+		// so the response time does not reveal which one matched.
+		matched := 0
+		for _, expectedKey := range expectedKeys {
+			matched |= subtle.ConstantTimeCompare([]byte(providedKey), []byte(expectedKey))
+		}
+		if matched != 1 {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid API key"})
 			return
 		}
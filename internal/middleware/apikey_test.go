@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/tenant"
 )
 
 func TestAPIKeyAuth_Success(t *testing.T) {
@@ -102,3 +105,94 @@ func TestAPIKeyAuth_TimingAttackResistance(t *testing.T) {
 		}
 	}
 }
+
+func TestAPIKeyAuth_MultipleKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(APIKeyAuth("first-key", "second-key"))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	testCases := []struct {
+		key      string
+		expected int
+	}{
+		{"first-key", http.StatusOK},
+		{"second-key", http.StatusOK},
+		{"third-key", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(apiKeyHeader, tc.key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.expected {
+			t.Errorf("key %q: expected status %d, got %d", tc.key, tc.expected, w.Code)
+		}
+	}
+}
+
+func TestTenantAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	directory, err := tenant.NewDirectory([]tenant.Config{
+		{ID: "payments", APIKeys: []string{"tenant-key"}, CORSOrigins: []string{"https://pay.example.com"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(TenantAPIKeyAuth(directory, "shared-key"))
+	router.GET("/test", func(c *gin.Context) {
+		id := ""
+		if current, ok := tenant.FromGinContext(c); ok {
+			id = current.ID
+		}
+		if fromCtx, ok := tenant.FromContext(c.Request.Context()); ok && fromCtx.ID != id {
+			t.Errorf("request context tenant %q differs from gin context tenant %q", fromCtx.ID, id)
+		}
+		c.JSON(http.StatusOK, gin.H{"tenant": id})
+	})
+
+	testCases := []struct {
+		name           string
+		key            string
+		origin         string
+		expectedStatus int
+		expectedTenant string
+	}{
+		{"tenant key", "tenant-key", "", http.StatusOK, "payments"},
+		{"tenant key from allowed origin", "tenant-key", "https://pay.example.com", http.StatusOK, "payments"},
+		{"tenant key from other origin", "tenant-key", "https://evil.example.com", http.StatusForbidden, ""},
+		{"shared key", "shared-key", "https://evil.example.com", http.StatusOK, ""},
+		{"unknown key", "unknown", "", http.StatusForbidden, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(apiKeyHeader, tc.key)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if w.Code == http.StatusOK {
+				var body map[string]string
+				_ = json.Unmarshal(w.Body.Bytes(), &body)
+				if body["tenant"] != tc.expectedTenant {
+					t.Errorf("expected tenant %q, got %q", tc.expectedTenant, body["tenant"])
+				}
+			}
+		})
+	}
+}
@@ -10,13 +10,14 @@ import (
 // CORS configures Cross-Origin Resource Sharing settings.
 // Reads allowed origins from CORS_ALLOWED_ORIGINS env var (comma-separated).
 // Defaults to allowing all origins if not specified (for development).
-func CORS() gin.HandlerFunc {
+// extraOrigins, such as the tenants' origins, are allowed in addition to the configured ones.
+func CORS(extraOrigins ...string) gin.HandlerFunc {
 	allowedOrigins := os.Getenv("CORS_ALLOWED_ORIGINS")
 	if allowedOrigins == "" {
 		allowedOrigins = "*"
 	}
 
-	origins := strings.Split(allowedOrigins, ",")
+	origins := append(strings.Split(allowedOrigins, ","), extraOrigins...)
 	originsMap := make(map[string]bool)
 	for _, origin := range origins {
 		originsMap[strings.TrimSpace(origin)] = true
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/tenant"
 )
 
 type rateLimiter struct {
@@ -41,10 +43,14 @@ func NewRateLimiter() *rateLimiter {
 		}
 	}
 
+	return newRateLimiter(rate, time.Duration(windowSeconds)*time.Second)
+}
+
+func newRateLimiter(rate int, window time.Duration) *rateLimiter {
 	rl := &rateLimiter{
 		requests:  make(map[string]*clientBucket),
 		rate:      rate,
-		window:    time.Duration(windowSeconds) * time.Second,
+		window:    window,
 		cleanupCh: make(chan struct{}),
 	}
 
@@ -60,10 +66,7 @@ func (rl *rateLimiter) RateLimit() gin.HandlerFunc {
 		clientIP := c.ClientIP()
 
 		if !rl.allowRequest(clientIP) {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error":      "rate limit exceeded",
-				"retryAfter": int(rl.window.Seconds()),
-			})
+			rl.abort(c)
 			return
 		}
 
@@ -71,6 +74,13 @@ func (rl *rateLimiter) RateLimit() gin.HandlerFunc {
 	}
 }
 
+func (rl *rateLimiter) abort(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":      "rate limit exceeded",
+		"retryAfter": int(rl.window.Seconds()),
+	})
+}
+
 func (rl *rateLimiter) allowRequest(clientIP string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -126,3 +136,55 @@ func (rl *rateLimiter) cleanup() {
 func (rl *rateLimiter) Stop() {
 	close(rl.cleanupCh)
 }
+
+type tenantRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rateLimiter
+}
+
+// NewTenantRateLimiter creates a rate limiter applying each tenant's own limit per client IP.
+func NewTenantRateLimiter() *tenantRateLimiter {
+	return &tenantRateLimiter{limiters: make(map[string]*rateLimiter)}
+}
+
+// RateLimit is a middleware that limits requests per client IP with the limit of the tenant
+// authenticated by TenantAPIKeyAuth. Requests without tenant or tenant limit pass through.
+func (tl *tenantRateLimiter) RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, ok := tenant.FromGinContext(c)
+		if !ok || t.RateLimit == nil {
+			c.Next()
+			return
+		}
+
+		rl := tl.limiter(t)
+		if !rl.allowRequest(c.ClientIP()) {
+			rl.abort(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (tl *tenantRateLimiter) limiter(t *tenant.Tenant) *rateLimiter {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	rl, exists := tl.limiters[t.ID]
+	if !exists {
+		rl = newRateLimiter(t.RateLimit.Requests, t.RateLimit.Window())
+		tl.limiters[t.ID] = rl
+	}
+	return rl
+}
+
+// Stop stops the cleanup goroutines of every tenant limiter.
+func (tl *tenantRateLimiter) Stop() {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	for _, rl := range tl.limiters {
+		rl.Stop()
+	}
+}
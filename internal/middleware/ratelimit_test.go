@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/tenant"
+)
+
+func TestTenantRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	directory, err := tenant.NewDirectory([]tenant.Config{
+		{ID: "limited", APIKeys: []string{"limited-key"}, RateLimit: &tenant.RateLimit{Requests: 2, WindowSeconds: 60}},
+		{ID: "unlimited", APIKeys: []string{"unlimited-key"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	limiter := NewTenantRateLimiter()
+	defer limiter.Stop()
+
+	router := gin.New()
+	router.Use(TenantAPIKeyAuth(directory), limiter.RateLimit())
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(key string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if code := send("limited-key"); code != expected {
+			t.Errorf("request %d: expected status %d, got %d", i+1, expected, code)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if code := send("unlimited-key"); code != http.StatusOK {
+			t.Errorf("expected tenant without limit to pass, got %d", code)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package passwordleak
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// errInvalidPoint is returned when ciphertext bytes are not a compressed P-256 point.
+var errInvalidPoint = errors.New("passwordleak: invalid encrypted point")
+
+// Cipher is an EC commutative cipher on P-256: a message is hashed to a curve point and
+// multiplied by the secret key. Encrypting with two keys gives the same point in any order,
+// which lets the server re-encrypt a value the client can then decrypt with its own key.
+type Cipher struct {
+	curve elliptic.Curve
+	key   *big.Int
+}
+
+// NewCipher creates a Cipher with a fresh random key.
+func NewCipher() (*Cipher, error) {
+	curve := elliptic.P256()
+	order := curve.Params().N
+
+	for {
+		key, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, err
+		}
+		if key.Sign() > 0 {
+			return &Cipher{curve: curve, key: key}, nil
+		}
+	}
+}
+
+// NewCipherWithKey creates a Cipher from a big-endian key, which must be in [1, N) where N is
+// the order of P-256.
+func NewCipherWithKey(key []byte) (*Cipher, error) {
+	curve := elliptic.P256()
+	k := new(big.Int).SetBytes(key)
+	if k.Sign() <= 0 || k.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("passwordleak: key out of range")
+	}
+	return &Cipher{curve: curve, key: k}, nil
+}
+
+// Encrypt hashes message to the curve and encrypts the point. The result is a compressed point.
+func (c *Cipher) Encrypt(message []byte) []byte {
+	x, y := hashToCurve(c.curve, message)
+	x, y = c.curve.ScalarMult(x, y, c.key.Bytes())
+	return elliptic.MarshalCompressed(c.curve, x, y)
+}
+
+// ReEncrypt encrypts a point already encrypted with another key.
+func (c *Cipher) ReEncrypt(encrypted []byte) ([]byte, error) {
+	return c.multiply(encrypted, c.key)
+}
+
+// Decrypt removes this cipher's layer of encryption from a compressed point.
+func (c *Cipher) Decrypt(encrypted []byte) ([]byte, error) {
+	inverse := new(big.Int).ModInverse(c.key, c.curve.Params().N)
+	return c.multiply(encrypted, inverse)
+}
+
+func (c *Cipher) multiply(encrypted []byte, scalar *big.Int) ([]byte, error) {
+	x, y := elliptic.UnmarshalCompressed(c.curve, encrypted)
+	if x == nil {
+		return nil, errInvalidPoint
+	}
+	x, y = c.curve.ScalarMult(x, y, scalar.Bytes())
+	return elliptic.MarshalCompressed(c.curve, x, y), nil
+}
+
+// hashToCurve maps message to a point with try-and-increment: candidate x coordinates come
+// from a SHA-256 random oracle until one lies on the curve. The even square root is used as y.
+func hashToCurve(curve elliptic.Curve, message []byte) (*big.Int, *big.Int) {
+	params := curve.Params()
+	p := params.P
+	three := big.NewInt(3)
+
+	x := randomOracle(message, p)
+	for {
+		// y² = x³ - 3x + b (mod p)
+		y2 := new(big.Int).Exp(x, three, p)
+		y2.Sub(y2, new(big.Int).Mul(three, x))
+		y2.Add(y2, params.B)
+		y2.Mod(y2, p)
+
+		if y := new(big.Int).ModSqrt(y2, p); y != nil {
+			if y.Bit(0) == 1 {
+				y.Sub(p, y)
+			}
+			return x, y
+		}
+
+		x = randomOracle(x.Bytes(), p)
+	}
+}
+
+// randomOracle expands message into a uniform value below max by concatenating
+// SHA-256(counter || message) blocks, as in the private-join-and-compute RandomOracle.
+func randomOracle(message []byte, max *big.Int) *big.Int {
+	const hashBits = sha256.Size * 8
+
+	outputBits := max.BitLen() + hashBits
+	iterations := (outputBits + hashBits - 1) / hashBits
+	excessBits := iterations*hashBits - outputBits
+
+	output := new(big.Int)
+	for i := 1; i <= iterations; i++ {
+		input := append(big.NewInt(int64(i)).Bytes(), message...)
+		sum := sha256.Sum256(input)
+
+		output.Lsh(output, hashBits)
+		output.Add(output, new(big.Int).SetBytes(sum[:]))
+	}
+
+	output.Rsh(output, uint(excessBits))
+	return output.Mod(output, max)
+}
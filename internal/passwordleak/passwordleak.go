@@ -0,0 +1,116 @@
+// Package passwordleak implements the client side of the reCAPTCHA Enterprise private password
+// leak verification protocol. Credentials never leave the service in clear: the username is
+// reduced to a 26-bit lookup prefix and the username/password pair is scrypt-hashed and
+// encrypted with a commutative cipher before being sent.
+package passwordleak
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// LookupHashPrefixBits is the number of bits of the username hash sent to look up leaks.
+const LookupHashPrefixBits = 26
+
+// scrypt parameters of the credentials hash.
+const (
+	scryptN      = 1 << 12
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// Salts defined by the protocol; they must match the ones used to build the leak database.
+var (
+	usernameSalt = []byte{
+		0xc4, 0x94, 0xa3, 0x95, 0xf8, 0xc0, 0xe2, 0x3e, 0xa9, 0x23, 0x04, 0x78, 0x70, 0x2c, 0x72, 0x18,
+		0x56, 0x54, 0x99, 0xb3, 0xe9, 0x21, 0x18, 0x6c, 0x21, 0x1a, 0x01, 0x22, 0x3c, 0x45, 0x4a, 0xfa,
+	}
+	usernamePasswordSalt = []byte{
+		0x30, 0x76, 0x2a, 0xd2, 0x3f, 0x7b, 0xa1, 0x9b, 0xf8, 0xe3, 0x42, 0xfc, 0xa1, 0xa7, 0x8d, 0x06,
+		0xe6, 0x6b, 0xe4, 0xdb, 0xb8, 0x4f, 0x81, 0x53, 0xc5, 0x03, 0xc8, 0xdb, 0xbd, 0xde, 0xa5, 0x20,
+	}
+)
+
+// CanonicalizeUsername lowercases the username, strips the domain of an email address and
+// removes dots, so that variants of the same account share one entry.
+func CanonicalizeUsername(username string) string {
+	canonical := strings.ToLower(strings.TrimSpace(username))
+	if at := strings.LastIndex(canonical, "@"); at >= 0 {
+		canonical = canonical[:at]
+	}
+	return strings.ReplaceAll(canonical, ".", "")
+}
+
+// LookupHashPrefix returns the first LookupHashPrefixBits bits of the salted SHA-256 hash of a
+// canonical username, padded with zero bits to whole bytes.
+func LookupHashPrefix(canonicalUsername string) []byte {
+	sum := sha256.Sum256(append([]byte(canonicalUsername), usernameSalt...))
+
+	prefix := make([]byte, (LookupHashPrefixBits+7)/8)
+	copy(prefix, sum[:])
+	if rem := LookupHashPrefixBits % 8; rem != 0 {
+		prefix[len(prefix)-1] &= byte(0xff << (8 - rem))
+	}
+	return prefix
+}
+
+// HashCredentials derives the scrypt hash of a canonical username and password.
+func HashCredentials(canonicalUsername, password string) ([]byte, error) {
+	salt := append([]byte(canonicalUsername), usernamePasswordSalt...)
+	return scrypt.Key([]byte(canonicalUsername+password), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+}
+
+// Verification is the client state of a single leak check.
+type Verification struct {
+	// LookupHashPrefix is sent as privatePasswordLeakVerification.lookupHashPrefix.
+	LookupHashPrefix []byte
+	// EncryptedUserCredentialsHash is sent as privatePasswordLeakVerification.encryptedUserCredentialsHash.
+	EncryptedUserCredentialsHash []byte
+
+	cipher *Cipher
+}
+
+// NewVerification prepares the request fields for username and password with a fresh key.
+func NewVerification(username, password string) (*Verification, error) {
+	cipher, err := NewCipher()
+	if err != nil {
+		return nil, err
+	}
+	return newVerification(cipher, username, password)
+}
+
+func newVerification(cipher *Cipher, username, password string) (*Verification, error) {
+	canonical := CanonicalizeUsername(username)
+
+	hash, err := HashCredentials(canonical, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verification{
+		LookupHashPrefix:             LookupHashPrefix(canonical),
+		EncryptedUserCredentialsHash: cipher.Encrypt(hash),
+		cipher:                       cipher,
+	}, nil
+}
+
+// Leaked removes the client encryption from the hash re-encrypted by the server and reports
+// whether its SHA-256 starts with any of the leak match prefixes returned for the lookup prefix.
+func (v *Verification) Leaked(reencryptedUserCredentialsHash []byte, encryptedLeakMatchPrefixes [][]byte) (bool, error) {
+	serverEncrypted, err := v.cipher.Decrypt(reencryptedUserCredentialsHash)
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha256.Sum256(serverEncrypted)
+	for _, prefix := range encryptedLeakMatchPrefixes {
+		if len(prefix) > 0 && bytes.HasPrefix(sum[:], prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
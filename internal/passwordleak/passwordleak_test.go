@@ -0,0 +1,164 @@
+package passwordleak
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// Vectors below were computed with an independent implementation (Python hashlib and
+// plain-integer P-256 arithmetic) for the username "John.Doe@Example.com" and password "hunter2".
+const (
+	vectorKey            = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	vectorLookupPrefix   = "6eb87d40"
+	vectorCredentials    = "81772b679f2d0b6abee721299383e59bc0d51b22c2fe968605a6d9faec0448a8"
+	vectorCredentialsEC  = "02e386ad5fa17520824b1948bdeb4d5ba91c45760d11007a50968236098ad0b7d1"
+	vectorEncryptedCreds = "02a1ed205a1e753a08e4193b66e7a53766675e52b53d302d0bec2e51950163c713"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func TestCanonicalizeUsername(t *testing.T) {
+	testCases := []struct {
+		username string
+		expected string
+	}{
+		{"John.Doe@Example.com", "johndoe"},
+		{"  jane  ", "jane"},
+		{"first.last", "firstlast"},
+		{"a@b@example.com", "a@b"},
+		{"UPPER", "upper"},
+	}
+
+	for _, tc := range testCases {
+		if got := CanonicalizeUsername(tc.username); got != tc.expected {
+			t.Errorf("CanonicalizeUsername(%q) = %q, expected %q", tc.username, got, tc.expected)
+		}
+	}
+}
+
+func TestKnownVectors(t *testing.T) {
+	canonical := CanonicalizeUsername("John.Doe@Example.com")
+
+	if prefix := LookupHashPrefix(canonical); hex.EncodeToString(prefix) != vectorLookupPrefix {
+		t.Errorf("unexpected lookup prefix %x", prefix)
+	}
+
+	hash, err := HashCredentials(canonical, "hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hex.EncodeToString(hash) != vectorCredentials {
+		t.Errorf("unexpected credentials hash %x", hash)
+	}
+
+	curve := elliptic.P256()
+	x, y := hashToCurve(curve, hash)
+	if !curve.IsOnCurve(x, y) {
+		t.Fatal("hashed point is not on the curve")
+	}
+	if point := elliptic.MarshalCompressed(curve, x, y); hex.EncodeToString(point) != vectorCredentialsEC {
+		t.Errorf("unexpected hashed point %x", point)
+	}
+
+	cipher, err := NewCipherWithKey(mustHex(t, vectorKey))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if encrypted := cipher.Encrypt(hash); hex.EncodeToString(encrypted) != vectorEncryptedCreds {
+		t.Errorf("unexpected encrypted hash %x", encrypted)
+	}
+}
+
+func TestCipher_Commutative(t *testing.T) {
+	client, err := NewCipher()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server, err := NewCipher()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	message := []byte("credentials")
+
+	reencrypted, err := server.ReEncrypt(client.Encrypt(message))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decrypted, err := client.Decrypt(reencrypted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(decrypted, server.Encrypt(message)) {
+		t.Error("expected decrypting the client layer to leave the server encryption")
+	}
+
+	if _, err := client.Decrypt([]byte{0x02, 0x01}); err == nil {
+		t.Error("expected an error for an invalid point")
+	}
+}
+
+func TestVerification_Leaked(t *testing.T) {
+	server, err := NewCipherWithKey(mustHex(t, vectorKey))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// leakPrefix simulates the server side: the SHA-256 prefix of a leaked credential encrypted with its key.
+	leakPrefix := func(username, password string) []byte {
+		canonical := CanonicalizeUsername(username)
+		hash, err := HashCredentials(canonical, password)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sum := sha256.Sum256(server.Encrypt(hash))
+		return sum[:20]
+	}
+	leaks := [][]byte{leakPrefix("john.doe@example.com", "hunter2"), leakPrefix("johndoe", "letmein")}
+
+	testCases := []struct {
+		name     string
+		password string
+		leaked   bool
+	}{
+		{"leaked password", "hunter2", true},
+		{"other leaked password", "letmein", true},
+		{"safe password", "correct horse battery staple", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			verification, err := NewVerification("John.Doe@Example.com", tc.password)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if hex.EncodeToString(verification.LookupHashPrefix) != vectorLookupPrefix {
+				t.Errorf("unexpected lookup prefix %x", verification.LookupHashPrefix)
+			}
+
+			reencrypted, err := server.ReEncrypt(verification.EncryptedUserCredentialsHash)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			leaked, err := verification.Leaked(reencrypted, leaks)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if leaked != tc.leaked {
+				t.Errorf("expected leaked %v, got %v", tc.leaked, leaked)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"api-recaptcha/internal/service"
+)
+
+// Decision is the explicit verdict returned to callers for a verification.
+type Decision string
+
+const (
+	DecisionAllow     Decision = "allow"
+	DecisionChallenge Decision = "challenge"
+	DecisionBlock     Decision = "block"
+)
+
+// DefaultRuleName identifies the rule applied to actions without a dedicated policy.
+const DefaultRuleName = "default"
+
+// Built-in thresholds used when no default rule is configured.
+const (
+	DefaultAllowScore     = 0.5
+	DefaultChallengeScore = 0.3
+)
+
+// Rule maps a single action to its score thresholds.
+// Scores at or above AllowScore are allowed, scores at or above ChallengeScore
+// are challenged and anything below is blocked.
+//
+// OnUpstreamError is the decision returned when the provider cannot be reached.
+// Leaving it empty keeps the action fail-closed: the upstream error is returned to the caller.
+//
+// EnforceAction rejects tokens minted for a different action than the one requested.
+// It defaults to true when omitted.
+type Rule struct {
+	Action          string   `json:"action"`
+	AllowScore      float64  `json:"allowScore"`
+	ChallengeScore  float64  `json:"challengeScore"`
+	OnUpstreamError Decision `json:"onUpstreamError,omitempty"`
+	EnforceAction   *bool    `json:"enforceAction,omitempty"`
+}
+
+// Config is the file representation of the policy set.
+type Config struct {
+	Default *Rule  `json:"default,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Outcome is the decision taken for an assessment together with the rule that produced it.
+type Outcome struct {
+	Decision Decision `json:"decision"`
+	Rule     string   `json:"rule"`
+}
+
+// Engine evaluates assessments against the configured per-action rules.
+type Engine struct {
+	rules    map[string]Rule
+	fallback Rule
+}
+
+// NewEngine validates the configuration and builds an Engine from it.
+func NewEngine(cfg Config) (*Engine, error) {
+	fallback := Rule{
+		Action:         DefaultRuleName,
+		AllowScore:     DefaultAllowScore,
+		ChallengeScore: DefaultChallengeScore,
+	}
+	if cfg.Default != nil {
+		fallback = *cfg.Default
+		fallback.Action = DefaultRuleName
+	}
+	if err := fallback.validate(); err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]Rule, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rule.Action = strings.TrimSpace(rule.Action)
+		if rule.Action == "" {
+			return nil, fmt.Errorf("policy rule without action")
+		}
+		if _, exists := rules[rule.Action]; exists {
+			return nil, fmt.Errorf("duplicate policy rule for action %q", rule.Action)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules[rule.Action] = rule
+	}
+
+	return &Engine{rules: rules, fallback: fallback}, nil
+}
+
+// NewDefaultEngine returns an Engine that applies the built-in thresholds to every action.
+func NewDefaultEngine() *Engine {
+	engine, _ := NewEngine(Config{})
+	return engine
+}
+
+// LoadFile reads a JSON policy configuration from disk.
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy file: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse policy file: %w", err)
+	}
+
+	return NewEngine(cfg)
+}
+
+// Actions returns the sorted actions that have a rule of their own.
+func (e *Engine) Actions() []string {
+	actions := make([]string, 0, len(e.rules))
+	for action := range e.rules {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// Rule returns the rule that applies to the given action.
+func (e *Engine) Rule(action string) Rule {
+	if rule, ok := e.rules[strings.TrimSpace(action)]; ok {
+		return rule
+	}
+	return e.fallback
+}
+
+// EnforceAction marks the result invalid with reason ACTION_MISMATCH when the token was
+// minted for another action and the rule for the expected action enforces it.
+// Tokens without an action (e.g. checkbox challenges) cannot be compared and are left untouched.
+func (e *Engine) EnforceAction(expected string, result service.AssessmentResult) service.AssessmentResult {
+	expected = strings.TrimSpace(expected)
+	if expected == "" || !result.Valid || result.Action == "" || result.Action == expected {
+		return result
+	}
+
+	if rule := e.Rule(expected); rule.EnforceAction != nil && !*rule.EnforceAction {
+		return result
+	}
+
+	result.Valid = false
+	result.InvalidReason = service.InvalidReasonActionMismatch
+	return result
+}
+
+// Evaluate decides what to do with an assessment obtained for the given action.
+func (e *Engine) Evaluate(action string, result service.AssessmentResult) Outcome {
+	rule := e.Rule(action)
+	outcome := Outcome{Decision: DecisionBlock, Rule: rule.Action}
+
+	if !result.Valid {
+		return outcome
+	}
+
+	switch {
+	case result.Score >= rule.AllowScore:
+		outcome.Decision = DecisionAllow
+	case result.Score >= rule.ChallengeScore:
+		outcome.Decision = DecisionChallenge
+	}
+
+	return outcome
+}
+
+// Degrade returns the outcome configured for the action when the provider is unavailable.
+// The second value is false when the action is fail-closed.
+func (e *Engine) Degrade(action string) (Outcome, bool) {
+	rule := e.Rule(action)
+	if rule.OnUpstreamError == "" {
+		return Outcome{}, false
+	}
+	return Outcome{Decision: rule.OnUpstreamError, Rule: rule.Action}, true
+}
+
+func (r Rule) validate() error {
+	if r.AllowScore < 0 || r.AllowScore > 1 {
+		return fmt.Errorf("policy %q: allowScore must be between 0 and 1", r.Action)
+	}
+	if r.ChallengeScore < 0 || r.ChallengeScore > 1 {
+		return fmt.Errorf("policy %q: challengeScore must be between 0 and 1", r.Action)
+	}
+	if r.ChallengeScore > r.AllowScore {
+		return fmt.Errorf("policy %q: challengeScore cannot exceed allowScore", r.Action)
+	}
+	switch r.OnUpstreamError {
+	case "", DecisionAllow, DecisionChallenge, DecisionBlock:
+	default:
+		return fmt.Errorf("policy %q: unknown onUpstreamError decision %q", r.Action, r.OnUpstreamError)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"api-recaptcha/internal/service"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Rules: []Rule{
+			{Action: "login", AllowScore: 0.7, ChallengeScore: 0.4},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		action   string
+		result   service.AssessmentResult
+		decision Decision
+		rule     string
+	}{
+		{"allow above threshold", "login", service.AssessmentResult{Valid: true, Score: 0.9}, DecisionAllow, "login"},
+		{"challenge between thresholds", "login", service.AssessmentResult{Valid: true, Score: 0.5}, DecisionChallenge, "login"},
+		{"block below threshold", "login", service.AssessmentResult{Valid: true, Score: 0.1}, DecisionBlock, "login"},
+		{"block invalid token", "login", service.AssessmentResult{Valid: false, Score: 0.9}, DecisionBlock, "login"},
+		{"default for unknown action", "newsletter", service.AssessmentResult{Valid: true, Score: 0.5}, DecisionAllow, DefaultRuleName},
+		{"default challenge", "", service.AssessmentResult{Valid: true, Score: 0.3}, DecisionChallenge, DefaultRuleName},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			outcome := engine.Evaluate(tc.action, tc.result)
+			if outcome.Decision != tc.decision {
+				t.Errorf("expected decision %s, got %s", tc.decision, outcome.Decision)
+			}
+			if outcome.Rule != tc.rule {
+				t.Errorf("expected rule %s, got %s", tc.rule, outcome.Rule)
+			}
+		})
+	}
+}
+
+func TestNewEngine_InvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing action", Config{Rules: []Rule{{AllowScore: 0.5}}}},
+		{"duplicate action", Config{Rules: []Rule{{Action: "login", AllowScore: 0.5}, {Action: "login", AllowScore: 0.6}}}},
+		{"score out of range", Config{Rules: []Rule{{Action: "login", AllowScore: 1.5}}}},
+		{"challenge above allow", Config{Default: &Rule{AllowScore: 0.3, ChallengeScore: 0.6}}},
+		{"unknown degradation decision", Config{Rules: []Rule{{Action: "login", AllowScore: 0.5, OnUpstreamError: "maybe"}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewEngine(tc.cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	content := `{
+		"default": {"allowScore": 0.6, "challengeScore": 0.2},
+		"rules": [{"action": "signup", "allowScore": 0.8, "challengeScore": 0.5}]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write policy file: %v", err)
+	}
+
+	engine, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rule := engine.Rule("signup"); rule.AllowScore != 0.8 {
+		t.Errorf("expected signup allowScore 0.8, got %f", rule.AllowScore)
+	}
+	if rule := engine.Rule("unknown"); rule.Action != DefaultRuleName || rule.AllowScore != 0.6 {
+		t.Errorf("expected default rule with allowScore 0.6, got %+v", rule)
+	}
+}
+
+func TestEngine_Degrade(t *testing.T) {
+	engine, err := NewEngine(Config{
+		Default: &Rule{AllowScore: 0.5, ChallengeScore: 0.3, OnUpstreamError: DecisionChallenge},
+		Rules: []Rule{
+			{Action: "payment", AllowScore: 0.8, ChallengeScore: 0.6},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := engine.Degrade("payment"); ok {
+		t.Error("expected payment to be fail-closed")
+	}
+
+	outcome, ok := engine.Degrade("newsletter")
+	if !ok {
+		t.Fatal("expected default rule to be fail-open")
+	}
+	if outcome.Decision != DecisionChallenge || outcome.Rule != DefaultRuleName {
+		t.Errorf("unexpected degraded outcome: %+v", outcome)
+	}
+}
+
+func TestEngine_EnforceAction(t *testing.T) {
+	disabled := false
+	engine, err := NewEngine(Config{
+		Rules: []Rule{
+			{Action: "login", AllowScore: 0.5},
+			{Action: "newsletter", AllowScore: 0.5, EnforceAction: &disabled},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		expected string
+		result   service.AssessmentResult
+		valid    bool
+	}{
+		{"matching action", "login", service.AssessmentResult{Valid: true, Action: "login"}, true},
+		{"mismatch enforced", "login", service.AssessmentResult{Valid: true, Action: "newsletter"}, false},
+		{"mismatch enforced by default rule", "signup", service.AssessmentResult{Valid: true, Action: "newsletter"}, false},
+		{"mismatch not enforced", "newsletter", service.AssessmentResult{Valid: true, Action: "login"}, true},
+		{"no expected action", "", service.AssessmentResult{Valid: true, Action: "login"}, true},
+		{"token without action", "login", service.AssessmentResult{Valid: true}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := engine.EnforceAction(tc.expected, tc.result)
+			if result.Valid != tc.valid {
+				t.Errorf("expected valid %v, got %v", tc.valid, result.Valid)
+			}
+			if !tc.valid && result.InvalidReason != service.InvalidReasonActionMismatch {
+				t.Errorf("expected invalid reason %s, got %s", service.InvalidReasonActionMismatch, result.InvalidReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+const maxAnnotationReasons = 20
+
+// Annotation is the outcome reported for an assessment, as in the reCAPTCHA Enterprise
+// AnnotateAssessmentRequest.Annotation enum.
+type Annotation string
+
+const (
+	AnnotationLegitimate        Annotation = "LEGITIMATE"
+	AnnotationFraudulent        Annotation = "FRAUDULENT"
+	AnnotationPasswordCorrect   Annotation = "PASSWORD_CORRECT"
+	AnnotationPasswordIncorrect Annotation = "PASSWORD_INCORRECT"
+)
+
+// AnnotationReason explains an annotation, as in the AnnotateAssessmentRequest.Reason enum.
+type AnnotationReason string
+
+const (
+	AnnotationReasonChargeback          AnnotationReason = "CHARGEBACK"
+	AnnotationReasonChargebackFraud     AnnotationReason = "CHARGEBACK_FRAUD"
+	AnnotationReasonChargebackDispute   AnnotationReason = "CHARGEBACK_DISPUTE"
+	AnnotationReasonRefund              AnnotationReason = "REFUND"
+	AnnotationReasonRefundFraud         AnnotationReason = "REFUND_FRAUD"
+	AnnotationReasonTransactionAccepted AnnotationReason = "TRANSACTION_ACCEPTED"
+	AnnotationReasonTransactionDeclined AnnotationReason = "TRANSACTION_DECLINED"
+	AnnotationReasonPaymentHeuristics   AnnotationReason = "PAYMENT_HEURISTICS"
+	AnnotationReasonInitiatedTwoFactor  AnnotationReason = "INITIATED_TWO_FACTOR"
+	AnnotationReasonPassedTwoFactor     AnnotationReason = "PASSED_TWO_FACTOR"
+	AnnotationReasonFailedTwoFactor     AnnotationReason = "FAILED_TWO_FACTOR"
+	AnnotationReasonCorrectPassword     AnnotationReason = "CORRECT_PASSWORD"
+	AnnotationReasonIncorrectPassword   AnnotationReason = "INCORRECT_PASSWORD"
+	AnnotationReasonSocialSpam          AnnotationReason = "SOCIAL_SPAM"
+)
+
+var annotationReasons = map[AnnotationReason]bool{
+	AnnotationReasonChargeback:          true,
+	AnnotationReasonChargebackFraud:     true,
+	AnnotationReasonChargebackDispute:   true,
+	AnnotationReasonRefund:              true,
+	AnnotationReasonRefundFraud:         true,
+	AnnotationReasonTransactionAccepted: true,
+	AnnotationReasonTransactionDeclined: true,
+	AnnotationReasonPaymentHeuristics:   true,
+	AnnotationReasonInitiatedTwoFactor:  true,
+	AnnotationReasonPassedTwoFactor:     true,
+	AnnotationReasonFailedTwoFactor:     true,
+	AnnotationReasonCorrectPassword:     true,
+	AnnotationReasonIncorrectPassword:   true,
+	AnnotationReasonSocialSpam:          true,
+}
+
+// Annotator feeds the real outcome of an assessment back to the provider.
+type Annotator interface {
+	Annotate(ctx context.Context, assessmentID string, annotation Annotation, reasons []AnnotationReason) error
+}
+
+type annotateRequest struct {
+	Annotation Annotation         `json:"annotation,omitempty"`
+	Reasons    []AnnotationReason `json:"reasons,omitempty"`
+}
+
+// Annotate calls the Enterprise assessments.annotate method for the assessment with the given ID
+// (the last segment of AssessmentResult.Name).
+func (s *RecaptchaService) Annotate(ctx context.Context, assessmentID string, annotation Annotation, reasons []AnnotationReason) error {
+	if err := validateAnnotation(assessmentID, annotation, reasons); err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(s.endpoint, "/") + "/" + assessmentID + ":annotate"
+	_, err := s.post(ctx, url, annotateRequest{Annotation: annotation, Reasons: reasons})
+	return err
+}
+
+// validateAnnotation performs the basic checks on an annotation before calling upstream.
+func validateAnnotation(assessmentID string, annotation Annotation, reasons []AnnotationReason) error {
+	if !isAssessmentID(assessmentID) {
+		return apperrors.NewValidationError("invalid assessment id", nil)
+	}
+
+	switch annotation {
+	case AnnotationLegitimate, AnnotationFraudulent, AnnotationPasswordCorrect, AnnotationPasswordIncorrect:
+	case "":
+		if len(reasons) == 0 {
+			return apperrors.NewValidationError("annotation or reasons are required", nil)
+		}
+	default:
+		return apperrors.NewValidationError("unknown annotation", nil)
+	}
+
+	if len(reasons) > maxAnnotationReasons {
+		return apperrors.NewValidationError("too many annotation reasons", nil)
+	}
+	for _, reason := range reasons {
+		if !annotationReasons[reason] {
+			return apperrors.NewValidationError("unknown annotation reason: "+string(reason), nil)
+		}
+	}
+	return nil
+}
+
+// isAssessmentID rejects anything that could alter the annotate URL path.
+func isAssessmentID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func TestRecaptchaService_Annotate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/p/assessments/abc123:annotate" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		var req annotateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Annotation != AnnotationFraudulent || len(req.Reasons) != 1 || req.Reasons[0] != AnnotationReasonChargeback {
+			t.Errorf("unexpected annotation %+v", req)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL+"/v1/projects/p/assessments")
+	err := svc.Annotate(context.Background(), "abc123", AnnotationFraudulent, []AnnotationReason{AnnotationReasonChargeback})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecaptchaService_Annotate_Validation(t *testing.T) {
+	svc := NewRecaptchaService("api-key", "site-key", "http://127.0.0.1:0")
+
+	testCases := []struct {
+		name         string
+		assessmentID string
+		annotation   Annotation
+		reasons      []AnnotationReason
+	}{
+		{"path traversal in id", "../abc", AnnotationLegitimate, nil},
+		{"empty id", "", AnnotationLegitimate, nil},
+		{"unknown annotation", "abc123", "MAYBE", nil},
+		{"unknown reason", "abc123", AnnotationFraudulent, []AnnotationReason{"BECAUSE"}},
+		{"nothing to annotate", "abc123", "", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := svc.Annotate(context.Background(), tc.assessmentID, tc.annotation, tc.reasons)
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeValidationFailed {
+				t.Fatalf("expected %s, got %v", apperrors.ErrCodeValidationFailed, err)
+			}
+		})
+	}
+}
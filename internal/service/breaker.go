@@ -0,0 +1,183 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"api-recaptcha/internal/logger"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// Circuit breaker defaults used when BreakerConfig leaves a field unset.
+const (
+	DefaultBreakerFailureRatio = 0.5
+	DefaultBreakerMinRequests  = 10
+	DefaultBreakerWindow       = 60 * time.Second
+	DefaultBreakerCooldown     = 30 * time.Second
+)
+
+// BreakerConfig controls when the circuit opens and how it recovers.
+type BreakerConfig struct {
+	// FailureRatio is the share of failed calls within Window that opens the circuit.
+	FailureRatio float64
+	// MinRequests is the number of calls required in Window before the ratio is evaluated.
+	MinRequests int
+	// Window is the period over which calls are counted while closed.
+	Window time.Duration
+	// Cooldown is how long the circuit stays open before a probe call is let through.
+	Cooldown time.Duration
+}
+
+// CircuitBreaker fails fast while an upstream dependency keeps failing.
+// After Cooldown a single probe is allowed (half-open); its outcome closes or re-opens the circuit.
+type CircuitBreaker struct {
+	name   string
+	config BreakerConfig
+	now    func() time.Time
+
+	mu            sync.Mutex
+	state         BreakerState
+	windowStart   time.Time
+	requests      int
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker; name identifies it in logs.
+func NewCircuitBreaker(name string, config BreakerConfig) *CircuitBreaker {
+	if config.FailureRatio <= 0 || config.FailureRatio > 1 {
+		config.FailureRatio = DefaultBreakerFailureRatio
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = DefaultBreakerMinRequests
+	}
+	if config.Window <= 0 {
+		config.Window = DefaultBreakerWindow
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = DefaultBreakerCooldown
+	}
+
+	return &CircuitBreaker{
+		name:   name,
+		config: config,
+		now:    time.Now,
+		state:  BreakerClosed,
+	}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be followed by Record.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.Cooldown {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		b.probeInFlight = true
+		return true
+	case BreakerHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record reports the outcome of a call let through by Allow.
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+
+	if b.state == BreakerHalfOpen {
+		b.probeInFlight = false
+		if success {
+			b.transition(BreakerClosed)
+		} else {
+			b.transition(BreakerOpen)
+		}
+		return
+	}
+
+	if b.state != BreakerClosed {
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.config.Window {
+		b.resetWindow(now)
+	}
+
+	b.requests++
+	if !success {
+		b.failures++
+	}
+
+	if b.requests >= b.config.MinRequests &&
+		float64(b.failures)/float64(b.requests) >= b.config.FailureRatio {
+		b.transition(BreakerOpen)
+	}
+}
+
+// Discard reports that a call let through by Allow ended without telling anything about
+// upstream health (e.g. the caller canceled it). It only frees the half-open probe slot.
+func (b *CircuitBreaker) Discard() {
+	b.mu.Lock()
+	b.probeInFlight = false
+	b.mu.Unlock()
+}
+
+// State returns the current state, reporting an open circuit whose cooldown elapsed as half-open.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.config.Cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) transition(to BreakerState) {
+	if b.state == to {
+		return
+	}
+
+	logger.Log.Warn("circuit breaker state changed",
+		"breaker", b.name,
+		"from", b.state,
+		"to", to,
+		"requests", b.requests,
+		"failures", b.failures,
+	)
+
+	b.state = to
+	switch to {
+	case BreakerOpen:
+		b.openedAt = b.now()
+	case BreakerClosed:
+		b.resetWindow(b.now())
+	}
+}
+
+func (b *CircuitBreaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker("test", BreakerConfig{
+		FailureRatio: 0.5,
+		MinRequests:  4,
+		Window:       time.Minute,
+		Cooldown:     30 * time.Second,
+	})
+	breaker.now = func() time.Time { return now }
+
+	// Below MinRequests the circuit stays closed regardless of failures.
+	for i := 0; i < 3; i++ {
+		if !breaker.Allow() {
+			t.Fatal("expected closed circuit to allow calls")
+		}
+		breaker.Record(i == 0)
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("expected closed, got %s", state)
+	}
+
+	breaker.Allow()
+	breaker.Record(false)
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected open after 3/4 failures, got %s", state)
+	}
+	if breaker.Allow() {
+		t.Fatal("expected open circuit to reject calls")
+	}
+
+	// After the cooldown a single probe is let through.
+	now = now.Add(31 * time.Second)
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("expected half-open after cooldown, got %s", state)
+	}
+	if !breaker.Allow() {
+		t.Fatal("expected probe to be allowed")
+	}
+	if breaker.Allow() {
+		t.Fatal("expected only one probe in flight")
+	}
+
+	breaker.Record(false)
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected failed probe to re-open the circuit, got %s", state)
+	}
+
+	now = now.Add(31 * time.Second)
+	breaker.Allow()
+	breaker.Record(true)
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("expected successful probe to close the circuit, got %s", state)
+	}
+}
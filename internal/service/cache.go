@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/metrics"
+)
+
+// Result cache defaults used when NewCachingAssessor gets zero values.
+const (
+	// DefaultResultCacheTTL matches the lifetime of a reCAPTCHA token.
+	DefaultResultCacheTTL = 2 * time.Minute
+	// DefaultResultCacheHits serves one more verification of the token, e.g. by the app after the gateway.
+	DefaultResultCacheHits = 1
+)
+
+// tokenLifetimes is how long each provider accepts a token after it was issued.
+var tokenLifetimes = map[string]time.Duration{
+	ProviderEnterprise: 2 * time.Minute,
+	ProviderSiteVerify: 2 * time.Minute,
+	ProviderTurnstile:  5 * time.Minute,
+	ProviderHCaptcha:   5 * time.Minute,
+}
+
+// tokenExpiry returns when the token behind result stops being accepted by its provider.
+// Tokens without a creation time are assumed to have just been issued.
+func tokenExpiry(result AssessmentResult, now time.Time) time.Time {
+	lifetime, ok := tokenLifetimes[result.Provider]
+	if !ok {
+		lifetime = DefaultReplayTTL
+	}
+	if result.CreateTime.IsZero() {
+		return now.Add(lifetime)
+	}
+	return result.CreateTime.Add(lifetime)
+}
+
+// ResultCache keeps assessment results for a short time and a limited number of hits.
+// Implementations must be safe for concurrent use; a shared backend lets
+// several instances serve a token verified by any of them.
+type ResultCache interface {
+	// Take returns the result stored under key, if any, and uses up one of its hits.
+	// The entry is forgotten once it has none left.
+	Take(ctx context.Context, key string) (AssessmentResult, bool, error)
+	// Set stores result under key for ttl, to be served at most hits times.
+	Set(ctx context.Context, key string, result AssessmentResult, hits int, ttl time.Duration) error
+}
+
+type cachedResult struct {
+	result    AssessmentResult
+	hits      int
+	expiresAt time.Time
+}
+
+// MemoryResultCache is an in-process ResultCache.
+type MemoryResultCache struct {
+	mu        sync.Mutex
+	entries   map[string]cachedResult
+	now       func() time.Time
+	cleanupCh chan struct{}
+}
+
+// NewMemoryResultCache creates a MemoryResultCache and starts its cleanup goroutine.
+func NewMemoryResultCache() *MemoryResultCache {
+	c := &MemoryResultCache{
+		entries:   make(map[string]cachedResult),
+		now:       time.Now,
+		cleanupCh: make(chan struct{}),
+	}
+
+	go c.cleanup(time.Minute)
+
+	return c
+}
+
+// Take implements ResultCache.
+func (c *MemoryResultCache) Take(_ context.Context, key string) (AssessmentResult, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists || !c.now().Before(entry.expiresAt) {
+		return AssessmentResult{}, false, nil
+	}
+
+	entry.hits--
+	if entry.hits <= 0 {
+		delete(c.entries, key)
+	} else {
+		c.entries[key] = entry
+	}
+	return entry.result, true, nil
+}
+
+// Set implements ResultCache.
+func (c *MemoryResultCache) Set(_ context.Context, key string, result AssessmentResult, hits int, ttl time.Duration) error {
+	c.mu.Lock()
+	c.entries[key] = cachedResult{result: result, hits: hits, expiresAt: c.now().Add(ttl)}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *MemoryResultCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			now := c.now()
+			for key, entry := range c.entries {
+				if !now.Before(entry.expiresAt) {
+					delete(c.entries, key)
+				}
+			}
+			c.mu.Unlock()
+		case <-c.cleanupCh:
+			return
+		}
+	}
+}
+
+// Stop stops the cleanup goroutine.
+func (c *MemoryResultCache) Stop() {
+	close(c.cleanupCh)
+}
+
+// CachingAssessor serves repeated verifications of the same token from a cache, so a token
+// checked by several backend services costs a single upstream assessment. Only identical
+// verifications share a result (see assessmentKey), and each result is served a limited number
+// of times: past that the token is treated as replayed again.
+type CachingAssessor struct {
+	next  Assessor
+	cache ResultCache
+	ttl   time.Duration
+	hits  int
+	now   func() time.Time
+}
+
+// NewCachingAssessor wraps next with a result cache. Entries live for ttl, and never past the
+// expiry of their token, and serve at most hits further verifications.
+func NewCachingAssessor(next Assessor, cache ResultCache, ttl time.Duration, hits int) *CachingAssessor {
+	if ttl <= 0 {
+		ttl = DefaultResultCacheTTL
+	}
+	if hits <= 0 {
+		hits = DefaultResultCacheHits
+	}
+	return &CachingAssessor{next: next, cache: cache, ttl: ttl, hits: hits, now: time.Now}
+}
+
+// Assess returns the cached result marked as cached when there is one, and otherwise
+// calls next and caches its result. Failed calls are not cached.
+func (a *CachingAssessor) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	key, err := assessmentKey(ctx, a.next, token, action)
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+
+	result, found, err := a.cache.Take(ctx, key)
+	if err != nil {
+		logger.Log.Error("result cache unavailable, skipping lookup", "error", err)
+	}
+	if found {
+		metrics.Inc(metrics.CachedAssessments, metrics.Action(action))
+		logger.Log.Debug("assessment served from cache", "action", action)
+		result.Cached = true
+		return result, nil
+	}
+
+	result, err = a.next.Assess(ctx, token, action)
+	if err != nil {
+		return result, err
+	}
+
+	now := a.now()
+	ttl := a.ttl
+	if untilExpiry := tokenExpiry(result, now).Sub(now); untilExpiry < ttl {
+		ttl = untilExpiry
+	}
+	if ttl > 0 {
+		if err := a.cache.Set(ctx, key, result, a.hits, ttl); err != nil {
+			logger.Log.Error("failed to store assessment in result cache", "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Scope implements Scoper on behalf of the wrapped assessor.
+func (a *CachingAssessor) Scope(ctx context.Context) (string, error) {
+	return scopeOf(ctx, a.next)
+}
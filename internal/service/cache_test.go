@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func TestCachingAssessor_ServesRepeatedVerification(t *testing.T) {
+	cache := NewMemoryResultCache()
+	defer cache.Stop()
+	replay := NewMemoryReplayStore()
+	defer replay.Stop()
+
+	upstream := &stubAssessor{result: AssessmentResult{Valid: true, Score: 0.9, Provider: ProviderEnterprise}}
+	cached := NewCachingAssessor(NewReplayGuard(upstream, replay, time.Minute), cache, time.Minute, 1)
+
+	first, err := cached.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Cached {
+		t.Error("expected the first verification not to be cached")
+	}
+
+	second, err := cached.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.Cached || !second.Valid || second.Score != 0.9 {
+		t.Errorf("expected the cached result, got %+v", second)
+	}
+	if upstream.calls != 1 {
+		t.Errorf("expected a single upstream call, got %d", upstream.calls)
+	}
+
+	// The entry is used up: a third verification is a replay again.
+	third, err := cached.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if third.Cached || third.Valid || third.InvalidReason != InvalidReasonDupe {
+		t.Errorf("expected the third verification to be rejected as DUPE, got %+v", third)
+	}
+
+	other, err := cached.Assess(context.Background(), "token", "signup")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if other.Cached || other.InvalidReason != InvalidReasonDupe || upstream.calls != 1 {
+		t.Errorf("expected another action to miss the cache and hit the replay guard, got %+v", other)
+	}
+}
+
+func TestCachingAssessor_HitsPerEntry(t *testing.T) {
+	cache := NewMemoryResultCache()
+	defer cache.Stop()
+
+	upstream := &stubAssessor{result: AssessmentResult{Valid: true}}
+	cached := NewCachingAssessor(upstream, cache, time.Minute, 3)
+
+	served := 0
+	for i := 0; i < 6; i++ {
+		result, err := cached.Assess(context.Background(), "token", "login")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Cached {
+			served++
+		}
+	}
+	// Each upstream result is served from the cache 3 times.
+	if served != 4 || upstream.calls != 2 {
+		t.Errorf("expected 4 cached results and 2 upstream calls, got %d and %d", served, upstream.calls)
+	}
+}
+
+func TestCachingAssessor_IsolatesSitesAndTenants(t *testing.T) {
+	base := context.Background()
+
+	testCases := []struct {
+		name   string
+		first  context.Context
+		second context.Context
+	}{
+		{"different sites", ContextWithSite(base, SiteSelector{Name: "a"}), ContextWithSite(base, SiteSelector{Name: "b"})},
+		{"different tenants", ContextWithPartition(base, "tenant:a"), ContextWithPartition(base, "tenant:b")},
+		{"different accounts", ContextWithEventDetails(base, EventDetails{AccountID: "a"}), ContextWithEventDetails(base, EventDetails{AccountID: "b"})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := NewMemoryResultCache()
+			defer cache.Stop()
+
+			upstream := &stubAssessor{result: AssessmentResult{Valid: true}}
+			cached := NewCachingAssessor(siteScopedAssessor{upstream}, cache, time.Minute, 1)
+
+			if _, err := cached.Assess(tc.first, "token", "login"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := cached.Assess(tc.second, "token", "login")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Cached || upstream.calls != 2 {
+				t.Errorf("expected the second request to be assessed on its own, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestCachingAssessor_TTLBoundedByTokenExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		createTime time.Time
+		provider   string
+		elapsed    time.Duration
+		wantCached bool
+	}{
+		{name: "within configured ttl", createTime: now, provider: ProviderEnterprise, elapsed: 50 * time.Second, wantCached: true},
+		{name: "past configured ttl", createTime: now, provider: ProviderEnterprise, elapsed: 61 * time.Second, wantCached: false},
+		{name: "token about to expire", createTime: now.Add(-110 * time.Second), provider: ProviderEnterprise, elapsed: 11 * time.Second, wantCached: false},
+		{name: "longer-lived turnstile token", createTime: now.Add(-110 * time.Second), provider: ProviderTurnstile, elapsed: 11 * time.Second, wantCached: true},
+		{name: "expired token", createTime: now.Add(-3 * time.Minute), provider: ProviderEnterprise, elapsed: 0, wantCached: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemoryResultCache()
+			defer cache.Stop()
+
+			clock := now
+			cache.now = func() time.Time { return clock }
+
+			upstream := &stubAssessor{result: AssessmentResult{Valid: true, Provider: tt.provider, CreateTime: tt.createTime}}
+			cached := NewCachingAssessor(upstream, cache, time.Minute, 0)
+			cached.now = func() time.Time { return clock }
+
+			if _, err := cached.Assess(context.Background(), "token", "login"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			clock = clock.Add(tt.elapsed)
+
+			result, err := cached.Assess(context.Background(), "token", "login")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Cached != tt.wantCached {
+				t.Errorf("expected cached=%v, got %+v", tt.wantCached, result)
+			}
+		})
+	}
+}
+
+func TestCachingAssessor_DoesNotCacheFailures(t *testing.T) {
+	cache := NewMemoryResultCache()
+	defer cache.Stop()
+
+	upstream := &stubAssessor{err: apperrors.NewRecaptchaError("down", nil)}
+	cached := NewCachingAssessor(upstream, cache, time.Minute, 0)
+
+	if _, err := cached.Assess(context.Background(), "token", "login"); err == nil {
+		t.Fatal("expected upstream error")
+	}
+
+	upstream.err = nil
+	upstream.result = AssessmentResult{Valid: true}
+	result, err := cached.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Cached || upstream.calls != 2 {
+		t.Errorf("expected the failure not to be cached, got %+v after %d calls", result, upstream.calls)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// TokenConstraints are local checks applied to valid tokens of a site key, on top of
+// the verdict returned by the provider. Empty fields disable the matching check.
+type TokenConstraints struct {
+	// MaxAge rejects tokens created longer ago than this.
+	MaxAge time.Duration
+	// Hostnames allows web tokens from these hosts. A "*." prefix also matches any subdomain.
+	Hostnames []string
+	// AndroidPackageNames allows Android tokens from these apps.
+	AndroidPackageNames []string
+	// IOSBundleIDs allows iOS tokens from these apps.
+	IOSBundleIDs []string
+}
+
+// check returns the reason why result violates the constraints, or an empty reason.
+// A token is checked against the allow-list of its own platform only.
+func (c TokenConstraints) check(result AssessmentResult, now time.Time) InvalidReason {
+	if c.MaxAge > 0 && !result.CreateTime.IsZero() && now.Sub(result.CreateTime) > c.MaxAge {
+		return InvalidReasonStale
+	}
+
+	switch {
+	case result.AndroidPackageName != "":
+		if len(c.AndroidPackageNames) > 0 && !containsFold(c.AndroidPackageNames, result.AndroidPackageName) {
+			return InvalidReasonAndroidPackageMismatch
+		}
+	case result.IOSBundleID != "":
+		if len(c.IOSBundleIDs) > 0 && !containsFold(c.IOSBundleIDs, result.IOSBundleID) {
+			return InvalidReasonIOSBundleMismatch
+		}
+	default:
+		if len(c.Hostnames) > 0 && !matchHostname(c.Hostnames, result.Hostname) {
+			return InvalidReasonHostnameMismatch
+		}
+	}
+
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchHostname(patterns []string, hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	if hostname == "" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(hostname, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if hostname == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenConstraints_Check(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	constraints := TokenConstraints{
+		MaxAge:              time.Minute,
+		Hostnames:           []string{"example.com", "*.example.org"},
+		AndroidPackageNames: []string{"com.example.app"},
+		IOSBundleIDs:        []string{"com.example.ios"},
+	}
+
+	testCases := []struct {
+		name     string
+		result   AssessmentResult
+		expected InvalidReason
+	}{
+		{"allowed hostname", AssessmentResult{Hostname: "example.com", CreateTime: now}, ""},
+		{"hostname is case-insensitive", AssessmentResult{Hostname: "EXAMPLE.com"}, ""},
+		{"wildcard subdomain", AssessmentResult{Hostname: "shop.example.org"}, ""},
+		{"wildcard does not match apex", AssessmentResult{Hostname: "example.org"}, InvalidReasonHostnameMismatch},
+		{"unknown hostname", AssessmentResult{Hostname: "evil.com"}, InvalidReasonHostnameMismatch},
+		{"missing hostname", AssessmentResult{}, InvalidReasonHostnameMismatch},
+		{"stale token", AssessmentResult{Hostname: "example.com", CreateTime: now.Add(-2 * time.Minute)}, InvalidReasonStale},
+		{"allowed android package", AssessmentResult{AndroidPackageName: "com.example.app"}, ""},
+		{"unknown android package", AssessmentResult{AndroidPackageName: "com.evil.app"}, InvalidReasonAndroidPackageMismatch},
+		{"allowed ios bundle", AssessmentResult{IOSBundleID: "com.example.ios"}, ""},
+		{"unknown ios bundle", AssessmentResult{IOSBundleID: "com.evil.ios"}, InvalidReasonIOSBundleMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if reason := constraints.check(tc.result, now); reason != tc.expected {
+				t.Errorf("expected reason %q, got %q", tc.expected, reason)
+			}
+		})
+	}
+
+	if reason := (TokenConstraints{}).check(AssessmentResult{Hostname: "evil.com"}, now); reason != "" {
+		t.Errorf("expected empty constraints to accept any token, got %q", reason)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+const (
+	maxAccountIDLength   = 256
+	maxUserAgentLength   = 1024
+	maxJA3Length         = 512
+	maxEventHeaders      = 50
+	maxEventHeaderLength = 2048
+)
+
+// EventDetails is optional information about the protected event, sent along with the token
+// to providers that use it. Providers that do not support a field ignore it.
+type EventDetails struct {
+	// AccountID identifies the end user's account. It is hashed with a server-side salt
+	// before it leaves the service.
+	AccountID string
+	// Transaction describes the payment being made, for fraud prevention assessments.
+	Transaction *TransactionData
+
+	// Signals about the end user's client.
+	UserIPAddress string
+	UserAgent     string
+	JA3           string
+	// Headers are request headers formatted as "Name: value".
+	Headers []string
+}
+
+type eventDetailsKey struct{}
+
+// ContextWithEventDetails attaches details to ctx so they reach the provider through any
+// chain of wrapping assessors.
+func ContextWithEventDetails(ctx context.Context, details EventDetails) context.Context {
+	return context.WithValue(ctx, eventDetailsKey{}, details)
+}
+
+// EventDetailsFromContext returns the details attached to ctx, or zero details.
+func EventDetailsFromContext(ctx context.Context) EventDetails {
+	details, _ := ctx.Value(eventDetailsKey{}).(EventDetails)
+	return details
+}
+
+// validate performs the basic checks on the details before calling upstream.
+func (d EventDetails) validate() error {
+	if len(d.AccountID) > maxAccountIDLength {
+		return apperrors.NewValidationError("account identifier too long", nil)
+	}
+	if d.UserIPAddress != "" && net.ParseIP(d.UserIPAddress) == nil {
+		return apperrors.NewValidationError("userIpAddress is not a valid IP address", nil)
+	}
+	if len(d.UserAgent) > maxUserAgentLength {
+		return apperrors.NewValidationError("user agent too long", nil)
+	}
+	if len(d.JA3) > maxJA3Length {
+		return apperrors.NewValidationError("ja3 fingerprint too long", nil)
+	}
+	if len(d.Headers) > maxEventHeaders {
+		return apperrors.NewValidationError("too many headers", nil)
+	}
+	for _, header := range d.Headers {
+		if len(header) > maxEventHeaderLength {
+			return apperrors.NewValidationError("header too long", nil)
+		}
+	}
+	if d.Transaction != nil {
+		return d.Transaction.Validate()
+	}
+	return nil
+}
+
+// hashAccountID derives a stable, non-reversible account identifier with HMAC-SHA256 keyed by salt.
+func hashAccountID(salt []byte, accountID string) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(strings.TrimSpace(accountID)))
+	return mac.Sum(nil)
+}
+
+// accountFields returns the hashed account identifier in both forms accepted by Enterprise.
+func accountFields(salt []byte, accountID string) ([]byte, *userInfo) {
+	hashed := hashAccountID(salt, accountID)
+	return hashed, &userInfo{AccountID: hex.EncodeToString(hashed)}
+}
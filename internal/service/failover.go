@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/logger"
+)
+
+// Failover defaults used when FailoverConfig leaves a field unset.
+const (
+	DefaultFailoverThreshold = 3
+	DefaultFailoverCooldown  = 30 * time.Second
+)
+
+// NamedAssessor pairs an Assessor with the provider name reported in results.
+type NamedAssessor struct {
+	Name     string
+	Assessor Assessor
+}
+
+// FailoverConfig controls when a provider is taken out of the rotation.
+type FailoverConfig struct {
+	// FailureThreshold is the number of consecutive upstream failures that marks a provider unhealthy.
+	FailureThreshold int
+	// Cooldown is how long an unhealthy provider is skipped before it is tried again.
+	Cooldown time.Duration
+}
+
+// ProviderHealth is a snapshot of the health tracked for a provider in the chain.
+type ProviderHealth struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	UnhealthyUntil      time.Time `json:"unhealthyUntil,omitempty"`
+}
+
+type failoverProvider struct {
+	NamedAssessor
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	unhealthyUntil      time.Time
+}
+
+// FailoverAssessor tries a chain of providers in order, moving to the next one
+// when a provider fails upstream and skipping providers that keep failing.
+type FailoverAssessor struct {
+	providers []*failoverProvider
+	config    FailoverConfig
+	now       func() time.Time
+}
+
+// NewFailoverAssessor builds a FailoverAssessor over the given providers, in priority order.
+func NewFailoverAssessor(config FailoverConfig, providers ...NamedAssessor) *FailoverAssessor {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultFailoverThreshold
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = DefaultFailoverCooldown
+	}
+
+	chain := make([]*failoverProvider, 0, len(providers))
+	for _, provider := range providers {
+		chain = append(chain, &failoverProvider{NamedAssessor: provider})
+	}
+
+	return &FailoverAssessor{
+		providers: chain,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// Assess returns the verdict of the first provider that answers. Healthy providers are
+// tried first in chain order; unhealthy ones are only used once every healthy one has failed.
+// Errors caused by the request itself (validation) are returned without failing over.
+func (f *FailoverAssessor) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	var lastErr error
+
+	for attempt, provider := range f.ordered() {
+		if attempt > 0 {
+			logger.Log.Warn("failing over to next verification provider",
+				"provider", provider.Name,
+				"attempt", attempt+1,
+			)
+		}
+
+		result, err := provider.Assessor.Assess(ctx, token, action)
+		if err == nil {
+			f.recordSuccess(provider)
+			if result.Provider == "" {
+				result.Provider = provider.Name
+			}
+			return result, nil
+		}
+
+		if !IsUpstreamFailure(err) || ctx.Err() != nil {
+			return AssessmentResult{}, err
+		}
+
+		f.recordFailure(provider, err)
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = apperrors.NewRecaptchaError("no verification provider available", nil)
+	}
+	return AssessmentResult{}, lastErr
+}
+
+// Scope implements Scoper with the scopes of every provider in the chain, since any of them
+// may end up serving the request. A provider that cannot scope the request would reject it, so
+// it never serves it and does not fail the whole chain.
+func (f *FailoverAssessor) Scope(ctx context.Context) (string, error) {
+	scopes := make([]string, 0, len(f.providers))
+	for _, provider := range f.providers {
+		scope, err := scopeOf(ctx, provider.Assessor)
+		if err != nil {
+			scope = "!"
+		}
+		scopes = append(scopes, provider.Name+"="+scope)
+	}
+	return strings.Join(scopes, ","), nil
+}
+
+// Health returns the current health of every provider in chain order.
+func (f *FailoverAssessor) Health() []ProviderHealth {
+	now := f.now()
+	health := make([]ProviderHealth, 0, len(f.providers))
+	for _, provider := range f.providers {
+		provider.mu.Lock()
+		health = append(health, ProviderHealth{
+			Name:                provider.Name,
+			Healthy:             !now.Before(provider.unhealthyUntil),
+			ConsecutiveFailures: provider.consecutiveFailures,
+			UnhealthyUntil:      provider.unhealthyUntil,
+		})
+		provider.mu.Unlock()
+	}
+	return health
+}
+
+// ordered returns healthy providers first, followed by the ones still cooling down.
+func (f *FailoverAssessor) ordered() []*failoverProvider {
+	now := f.now()
+	healthy := make([]*failoverProvider, 0, len(f.providers))
+	var cooling []*failoverProvider
+
+	for _, provider := range f.providers {
+		provider.mu.Lock()
+		available := !now.Before(provider.unhealthyUntil)
+		provider.mu.Unlock()
+
+		if available {
+			healthy = append(healthy, provider)
+		} else {
+			cooling = append(cooling, provider)
+		}
+	}
+
+	return append(healthy, cooling...)
+}
+
+func (f *FailoverAssessor) recordSuccess(provider *failoverProvider) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.consecutiveFailures >= f.config.FailureThreshold {
+		logger.Log.Info("verification provider recovered", "provider", provider.Name)
+	}
+	provider.consecutiveFailures = 0
+	provider.unhealthyUntil = time.Time{}
+}
+
+func (f *FailoverAssessor) recordFailure(provider *failoverProvider, err error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	provider.consecutiveFailures++
+	logger.Log.Warn("verification provider failed",
+		"provider", provider.Name,
+		"consecutive_failures", provider.consecutiveFailures,
+		"error", err.Error(),
+	)
+
+	if provider.consecutiveFailures >= f.config.FailureThreshold {
+		provider.unhealthyUntil = f.now().Add(f.config.Cooldown)
+		logger.Log.Error("verification provider marked unhealthy",
+			"provider", provider.Name,
+			"cooldown", f.config.Cooldown.String(),
+		)
+	}
+}
+
+// IsUpstreamFailure reports whether err comes from the provider side (and another provider
+// may succeed) rather than from the request itself.
+func IsUpstreamFailure(err error) bool {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr.HTTPStatus >= 500
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+type stubAssessor struct {
+	calls  int
+	result AssessmentResult
+	err    error
+}
+
+func (s *stubAssessor) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func TestFailoverAssessor_FailsOverOnUpstreamError(t *testing.T) {
+	primary := &stubAssessor{err: apperrors.NewRecaptchaError("down", nil)}
+	secondary := &stubAssessor{result: AssessmentResult{Valid: true, Score: 0.9}}
+
+	failover := NewFailoverAssessor(FailoverConfig{},
+		NamedAssessor{Name: ProviderEnterprise, Assessor: primary},
+		NamedAssessor{Name: ProviderSiteVerify, Assessor: secondary},
+	)
+
+	result, err := failover.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Provider != ProviderSiteVerify {
+		t.Errorf("expected provider %s, got %s", ProviderSiteVerify, result.Provider)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Errorf("expected one call per provider, got %d and %d", primary.calls, secondary.calls)
+	}
+}
+
+func TestFailoverAssessor_DoesNotFailOverOnValidationError(t *testing.T) {
+	primary := &stubAssessor{err: apperrors.NewValidationError("token too long", nil)}
+	secondary := &stubAssessor{result: AssessmentResult{Valid: true}}
+
+	failover := NewFailoverAssessor(FailoverConfig{},
+		NamedAssessor{Name: ProviderEnterprise, Assessor: primary},
+		NamedAssessor{Name: ProviderSiteVerify, Assessor: secondary},
+	)
+
+	_, err := failover.Assess(context.Background(), "token", "login")
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeValidationFailed {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("expected secondary provider not to be called, got %d calls", secondary.calls)
+	}
+}
+
+func TestFailoverAssessor_SkipsUnhealthyProvider(t *testing.T) {
+	primary := &stubAssessor{err: apperrors.NewRecaptchaError("down", nil)}
+	secondary := &stubAssessor{result: AssessmentResult{Valid: true}}
+
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	failover := NewFailoverAssessor(FailoverConfig{FailureThreshold: 2, Cooldown: time.Minute},
+		NamedAssessor{Name: ProviderEnterprise, Assessor: primary},
+		NamedAssessor{Name: ProviderSiteVerify, Assessor: secondary},
+	)
+	failover.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := failover.Assess(context.Background(), "token", "login"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if primary.calls != 2 {
+		t.Errorf("expected primary to be skipped after 2 failures, got %d calls", primary.calls)
+	}
+	if health := failover.Health(); health[0].Healthy || !health[1].Healthy {
+		t.Errorf("unexpected health snapshot: %+v", health)
+	}
+
+	// Once the cooldown elapses the primary is tried again and recovers.
+	now = now.Add(2 * time.Minute)
+	primary.err = nil
+	result, err := failover.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Provider != ProviderEnterprise {
+		t.Errorf("expected provider %s after recovery, got %s", ProviderEnterprise, result.Provider)
+	}
+	if health := failover.Health(); !health[0].Healthy || health[0].ConsecutiveFailures != 0 {
+		t.Errorf("expected primary to be healthy, got %+v", health[0])
+	}
+}
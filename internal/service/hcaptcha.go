@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"api-recaptcha/internal/logger"
+)
+
+// DefaultHCaptchaEndpoint is the hCaptcha token verification endpoint.
+const DefaultHCaptchaEndpoint = "https://api.hcaptcha.com/siteverify"
+
+type hCaptchaResponse struct {
+	siteVerifyResponse
+	ScoreReason []string `json:"score_reason"`
+}
+
+// HCaptchaService verifies hCaptcha tokens.
+type HCaptchaService struct {
+	client   *http.Client
+	secret   string
+	siteKey  string
+	endpoint string
+}
+
+// NewHCaptchaService builds an HCaptchaService. An empty endpoint uses DefaultHCaptchaEndpoint.
+// When siteKey is set hCaptcha also checks that the token was issued for it.
+func NewHCaptchaService(secret, siteKey, endpoint string, opts ...VerifierOption) *HCaptchaService {
+	if endpoint == "" {
+		endpoint = DefaultHCaptchaEndpoint
+	}
+	return &HCaptchaService{
+		client:   newVerifierConfig(opts).client,
+		secret:   secret,
+		siteKey:  siteKey,
+		endpoint: endpoint,
+	}
+}
+
+// Assess validates the token against hCaptcha and normalizes the outcome.
+// hCaptcha Enterprise scores grow with risk, so they are inverted to match reCAPTCHA
+// where 1.0 means very likely human.
+func (s *HCaptchaService) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	form := url.Values{}
+	form.Set("secret", s.secret)
+	form.Set("response", token)
+	if s.siteKey != "" {
+		form.Set("sitekey", s.siteKey)
+	}
+
+	var verification hCaptchaResponse
+	if err := postSiteVerify(ctx, s.client, s.endpoint, form, &verification); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	if err := configurationError(verification.ErrorCodes, "invalid-input-secret", "missing-input-secret", "bad-request"); err != nil {
+		logger.Log.Error("hCaptcha rejected the request", "error_codes", verification.ErrorCodes)
+		return AssessmentResult{}, err
+	}
+
+	if verification.Score != nil {
+		inverted := 1 - *verification.Score
+		verification.Score = &inverted
+	}
+
+	result := verification.toResult()
+	result.Provider = ProviderHCaptcha
+	for _, reason := range verification.ScoreReason {
+		result.Reasons = append(result.Reasons, RiskReason(reason))
+	}
+	if !result.Valid {
+		result.InvalidReason = hCaptchaInvalidReason(verification.ErrorCodes)
+	}
+
+	return result, nil
+}
+
+func hCaptchaInvalidReason(codes []string) InvalidReason {
+	for _, code := range codes {
+		switch code {
+		case "missing-input-response":
+			return InvalidReasonMissing
+		case "invalid-input-response":
+			return InvalidReasonMalformed
+		case "expired-input-response":
+			return InvalidReasonExpired
+		case "already-seen-response":
+			return InvalidReasonDupe
+		case "sitekey-secret-mismatch":
+			return InvalidReasonSiteMismatch
+		}
+	}
+	return InvalidReasonUnknown
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHCaptchaService_Assess(t *testing.T) {
+	testCases := []struct {
+		name          string
+		response      string
+		valid         bool
+		score         float64
+		invalidReason InvalidReason
+	}{
+		{
+			name:     "enterprise score is inverted",
+			response: `{"success":true,"score":0.2,"hostname":"example.com","challenge_ts":"2026-01-15T10:00:00.000Z"}`,
+			valid:    true,
+			score:    0.8,
+		},
+		{
+			name:     "solved challenge without score",
+			response: `{"success":true,"hostname":"example.com"}`,
+			valid:    true,
+			score:    1,
+		},
+		{
+			name:          "duplicated token",
+			response:      `{"success":false,"error-codes":["already-seen-response"]}`,
+			invalidReason: "DUPE",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("failed to parse form: %v", err)
+				}
+				if r.PostForm.Get("sitekey") != "site-key" {
+					t.Errorf("expected sitekey to be forwarded, got %q", r.PostForm.Get("sitekey"))
+				}
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			svc := NewHCaptchaService("secret", "site-key", server.URL)
+			result, err := svc.Assess(context.Background(), "token", "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Valid != tc.valid {
+				t.Errorf("expected valid %v, got %v", tc.valid, result.Valid)
+			}
+			if diff := result.Score - tc.score; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("expected score %f, got %f", tc.score, result.Score)
+			}
+			if result.InvalidReason != tc.invalidReason {
+				t.Errorf("expected invalid reason %q, got %q", tc.invalidReason, result.InvalidReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/metrics"
+)
+
+// Hedging defaults used when HedgePolicy leaves a field unset.
+const (
+	DefaultHedgePercentile   = 0.95
+	DefaultHedgeMinDelay     = 50 * time.Millisecond
+	DefaultHedgeInitialDelay = 300 * time.Millisecond
+	DefaultHedgeWindow       = 200
+	// hedgeMinSamples is the number of observed latencies needed before the percentile is used.
+	hedgeMinSamples = 20
+)
+
+// HedgePolicy controls when a second assessment call is sent.
+type HedgePolicy struct {
+	// Percentile of recent call latencies after which the second call is sent, in (0, 1).
+	Percentile float64
+	// MinDelay is the shortest wait before hedging, however fast recent calls were.
+	MinDelay time.Duration
+	// InitialDelay is used until enough latencies have been observed.
+	InitialDelay time.Duration
+	// Window is the number of recent latencies kept.
+	Window int
+}
+
+// withDefaults fills unset fields with the package defaults.
+func (p HedgePolicy) withDefaults() HedgePolicy {
+	if p.Percentile <= 0 || p.Percentile >= 1 {
+		p.Percentile = DefaultHedgePercentile
+	}
+	if p.MinDelay <= 0 {
+		p.MinDelay = DefaultHedgeMinDelay
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultHedgeInitialDelay
+	}
+	if p.Window <= 0 {
+		p.Window = DefaultHedgeWindow
+	}
+	return p
+}
+
+// hedger tracks recent upstream latencies to derive the hedging delay.
+type hedger struct {
+	policy HedgePolicy
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newHedger(policy HedgePolicy) *hedger {
+	policy = policy.withDefaults()
+	return &hedger{policy: policy, samples: make([]time.Duration, 0, policy.Window)}
+}
+
+// observe records the latency of a completed call.
+func (h *hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < h.policy.Window {
+		h.samples = append(h.samples, latency)
+		return
+	}
+	h.samples[h.next] = latency
+	h.next = (h.next + 1) % h.policy.Window
+}
+
+// delay returns how long to wait for the first call before sending the second one.
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	if len(h.samples) < hedgeMinSamples {
+		h.mu.Unlock()
+		return h.policy.InitialDelay
+	}
+	sorted := append([]time.Duration(nil), h.samples...)
+	h.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(h.policy.Percentile*float64(len(sorted)))) - 1
+	if delay := sorted[index]; delay > h.policy.MinDelay {
+		return delay
+	}
+	return h.policy.MinDelay
+}
+
+// hedgedCall is the outcome of one of the concurrent calls.
+type hedgedCall struct {
+	resp    upstreamResponse
+	err     error
+	hedge   bool
+	latency time.Duration
+}
+
+// rank orders outcomes from most to least useful: a usable response, a definitive answer that
+// may have been caused by the other call (DUPE) or a 4xx, a transient failure, a transport error.
+func (c hedgedCall) rank() int {
+	switch {
+	case c.err != nil:
+		return 3
+	case c.resp.status == http.StatusTooManyRequests || c.resp.status >= 500:
+		return 2
+	case c.resp.status != http.StatusOK || isDupeResponse(c.resp.body):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// isDupeResponse reports whether an assessment body says the token was already assessed. With
+// hedging this may be the doing of the other call, so the other answer is worth waiting for.
+func isDupeResponse(body []byte) bool {
+	var assessment struct {
+		TokenProperties struct {
+			InvalidReason InvalidReason `json:"invalidReason"`
+		} `json:"tokenProperties"`
+	}
+	return json.Unmarshal(body, &assessment) == nil && assessment.TokenProperties.InvalidReason == InvalidReasonDupe
+}
+
+// doHedged performs an assessment call, hedged when configured: if the first call has not
+// answered after the hedging delay a second one is sent, the first usable answer wins and the
+// other call is canceled. The first call gets through the outbound limiter before the hedging
+// delay starts, so waiting for quota is not taken for upstream latency, and the second call is
+// only sent when the limiter has quota to spare.
+func (s *RecaptchaService) doHedged(ctx context.Context, url string, body []byte) (upstreamResponse, error) {
+	if s.hedge == nil {
+		return s.doLimited(ctx, url, body)
+	}
+	if err := s.acquire(ctx); err != nil {
+		return upstreamResponse{}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgedCall, 2)
+	launch := func(hedge bool) {
+		start := time.Now()
+		go func() {
+			resp, err := s.do(ctx, url, body)
+			results <- hedgedCall{resp: resp, err: err, hedge: hedge, latency: time.Since(start)}
+		}()
+	}
+
+	start := time.Now()
+	launch(false)
+	inFlight, hedged, originalDone := 1, false, false
+	timer := time.NewTimer(s.hedge.delay())
+	defer timer.Stop()
+
+	var best *hedgedCall
+	for {
+		select {
+		case <-timer.C:
+			switch {
+			case ctx.Err() != nil:
+			case s.limiter != nil && !s.limiter.Allow():
+				metrics.Inc(metrics.HedgedRequests, "skipped")
+				logger.Log.Debug("not hedging slow reCAPTCHA Enterprise call, no outbound quota to spare")
+			default:
+				launch(true)
+				inFlight, hedged = inFlight+1, true
+				metrics.Inc(metrics.HedgedRequests, "fired")
+				logger.Log.Debug("hedging slow reCAPTCHA Enterprise call")
+			}
+			continue
+		case call := <-results:
+			inFlight--
+			// Only the original call is sampled: hedges are sent for the slowest calls alone, so
+			// their latencies would drag the percentile down and hedges would fire ever more often.
+			if !call.hedge {
+				originalDone = true
+				if call.err == nil {
+					s.hedge.observe(call.latency)
+				}
+			}
+			if best == nil || call.rank() < best.rank() {
+				best = &call
+			}
+		}
+
+		// Keep waiting while the other call may still improve on a failure or a DUPE. A first call
+		// failing before the hedging delay is returned as is, for the retry policy to handle.
+		if best.rank() > 0 && inFlight > 0 {
+			continue
+		}
+		if !originalDone {
+			// The original call is about to be canceled; it took at least this long, which keeps
+			// the slow tail in the samples.
+			s.hedge.observe(time.Since(start))
+		}
+		if hedged {
+			if best.hedge {
+				metrics.Inc(metrics.HedgedRequests, "won")
+			} else {
+				metrics.Inc(metrics.HedgedRequests, "lost")
+			}
+		}
+		return best.resp, best.err
+	}
+}
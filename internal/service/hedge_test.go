@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-recaptcha/internal/metrics"
+)
+
+func hedgeCount(key string) int64 {
+	if v, ok := metrics.HedgedRequests.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestHedger_Delay(t *testing.T) {
+	h := newHedger(HedgePolicy{Percentile: 0.95, MinDelay: 5 * time.Millisecond, InitialDelay: 200 * time.Millisecond, Window: 100})
+
+	if d := h.delay(); d != 200*time.Millisecond {
+		t.Errorf("expected initial delay before enough samples, got %v", d)
+	}
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if d := h.delay(); d != 95*time.Millisecond {
+		t.Errorf("expected p95 of 95ms, got %v", d)
+	}
+
+	// The window only keeps the most recent latencies.
+	for i := 0; i < 100; i++ {
+		h.observe(time.Millisecond)
+	}
+	if d := h.delay(); d != 5*time.Millisecond {
+		t.Errorf("expected the minimum delay, got %v", d)
+	}
+}
+
+func TestHedger_HedgeRateConvergesToPercentile(t *testing.T) {
+	const requests = 20000
+	policy := HedgePolicy{Percentile: 0.95, MinDelay: time.Millisecond, Window: 200}
+	h := newHedger(policy)
+	rng := rand.New(rand.NewPCG(1, 2))
+	latency := func() time.Duration {
+		return time.Duration(math.Exp(math.Log(150)+0.5*rng.NormFloat64()) * float64(time.Millisecond))
+	}
+
+	// Sample the way doHedged does: the original call's latency, or the time until it was
+	// canceled when the hedge answered first.
+	hedges := 0
+	for i := 0; i < requests; i++ {
+		delay, original := h.delay(), latency()
+		if original <= delay {
+			h.observe(original)
+			continue
+		}
+		hedges++
+		h.observe(min(original, delay+latency()))
+	}
+
+	if rate := float64(hedges) / requests; math.Abs(rate-(1-policy.Percentile)) > 0.01 {
+		t.Errorf("expected a hedge rate close to %.2f, got %.3f", 1-policy.Percentile, rate)
+	}
+}
+
+func TestRecaptchaService_Assess_Hedging(t *testing.T) {
+	const validBody = `{"tokenProperties": {"valid": true, "action": "login"}, "riskAnalysis": {"score": 0.9}}`
+	const dupeBody = `{"tokenProperties": {"valid": false, "invalidReason": "DUPE"}}`
+
+	testCases := []struct {
+		name          string
+		first         func(w http.ResponseWriter, r *http.Request)
+		second        func(w http.ResponseWriter, r *http.Request)
+		expectedCalls int32
+		expectedValid bool
+		won, lost     int64
+	}{
+		{
+			name:          "fast call is not hedged",
+			first:         func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(validBody)) },
+			expectedCalls: 1,
+			expectedValid: true,
+		},
+		{
+			name: "hedge wins over slow call",
+			first: func(w http.ResponseWriter, r *http.Request) {
+				// The server notices the client going away only once the body has been read.
+				_, _ = io.Copy(io.Discard, r.Body)
+				select {
+				case <-r.Context().Done():
+				case <-time.After(2 * time.Second):
+					t.Error("expected the slow call to be canceled")
+				}
+			},
+			second:        func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(validBody)) },
+			expectedCalls: 2,
+			expectedValid: true,
+			won:           1,
+		},
+		{
+			name: "DUPE from hedge waits for the original call",
+			first: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+				_, _ = w.Write([]byte(validBody))
+			},
+			second:        func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(dupeBody)) },
+			expectedCalls: 2,
+			expectedValid: true,
+			lost:          1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					tc.first(w, r)
+					return
+				}
+				tc.second(w, r)
+			}))
+			defer server.Close()
+
+			wonBefore, lostBefore := hedgeCount("won"), hedgeCount("lost")
+
+			svc := NewRecaptchaService("api-key", "site-key", server.URL, WithHedging(HedgePolicy{InitialDelay: 20 * time.Millisecond}))
+			result, err := svc.Assess(context.Background(), "token", "login")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Valid != tc.expectedValid {
+				t.Errorf("expected valid %v, got %+v", tc.expectedValid, result)
+			}
+			if calls.Load() != tc.expectedCalls {
+				t.Errorf("expected %d upstream calls, got %d", tc.expectedCalls, calls.Load())
+			}
+			if won := hedgeCount("won") - wonBefore; won != tc.won {
+				t.Errorf("expected %d hedge wins, got %d", tc.won, won)
+			}
+			if lost := hedgeCount("lost") - lostBefore; lost != tc.lost {
+				t.Errorf("expected %d hedge losses, got %d", tc.lost, lost)
+			}
+			if samples := svc.hedge.samples; len(samples) != 1 || (tc.expectedCalls > 1 && samples[0] < 20*time.Millisecond) {
+				t.Errorf("expected the original call alone to be sampled, got %v", samples)
+			}
+		})
+	}
+}
+
+func TestRecaptchaService_Assess_HedgingWithOutboundLimiter(t *testing.T) {
+	const validBody = `{"tokenProperties": {"valid": true, "action": "login"}, "riskAnalysis": {"score": 0.9}}`
+
+	testCases := []struct {
+		name          string
+		latency       time.Duration
+		drain         bool
+		expectedCalls int32
+		fired         int64
+		skipped       int64
+	}{
+		{
+			// The call waits ~100ms for quota but answers at once: the wait is not upstream latency.
+			name:          "time queued for quota does not trigger a hedge",
+			drain:         true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "slow call is not hedged without spare quota",
+			latency:       100 * time.Millisecond,
+			expectedCalls: 1,
+			skipped:       1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				time.Sleep(tc.latency)
+				_, _ = w.Write([]byte(validBody))
+			}))
+			defer server.Close()
+
+			limiter := NewOutboundLimiter(LimiterConfig{QPS: 10, Burst: 1})
+			if tc.drain {
+				if err := limiter.Wait(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			firedBefore, skippedBefore := hedgeCount("fired"), hedgeCount("skipped")
+
+			svc := NewRecaptchaService("api-key", "site-key", server.URL,
+				WithOutboundLimiter(limiter),
+				WithHedging(HedgePolicy{InitialDelay: 20 * time.Millisecond}))
+			result, err := svc.Assess(context.Background(), "token", "login")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !result.Valid {
+				t.Errorf("expected a valid result, got %+v", result)
+			}
+			if calls.Load() != tc.expectedCalls {
+				t.Errorf("expected %d upstream calls, got %d", tc.expectedCalls, calls.Load())
+			}
+			if fired := hedgeCount("fired") - firedBefore; fired != tc.fired {
+				t.Errorf("expected %d hedges fired, got %d", tc.fired, fired)
+			}
+			if skipped := hedgeCount("skipped") - skippedBefore; skipped != tc.skipped {
+				t.Errorf("expected %d hedges skipped, got %d", tc.skipped, skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/metrics"
+)
+
+// DefaultOutboundQueueSize is the number of calls allowed to wait for quota when LimiterConfig leaves it unset.
+const DefaultOutboundQueueSize = 100
+
+// LimiterConfig controls the outbound token bucket.
+type LimiterConfig struct {
+	// QPS is the sustained number of upstream calls per second.
+	QPS float64
+	// Burst is the number of calls allowed at once above the sustained rate; it defaults to QPS.
+	Burst int
+	// MaxQueue is the number of calls allowed to wait for a token.
+	MaxQueue int
+}
+
+// OutboundLimiter is a token bucket shared by every upstream call, so bursts of incoming traffic
+// stay within the provider's QPS quota. Calls without a token wait in a bounded queue, and only
+// as long as their context deadline allows. It is safe for concurrent use.
+type OutboundLimiter struct {
+	rate     float64
+	burst    float64
+	maxQueue int
+	now      func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	queued int
+}
+
+// NewOutboundLimiter creates a limiter with a full bucket. A QPS that is not positive and
+// finite means no limit: it returns nil, which lets every call through.
+func NewOutboundLimiter(cfg LimiterConfig) *OutboundLimiter {
+	if !(cfg.QPS > 0) || math.IsInf(cfg.QPS, 1) {
+		return nil
+	}
+
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(cfg.QPS))
+	}
+	if cfg.MaxQueue <= 0 {
+		cfg.MaxQueue = DefaultOutboundQueueSize
+	}
+
+	return &OutboundLimiter{
+		rate:     cfg.QPS,
+		burst:    float64(burst),
+		maxQueue: cfg.MaxQueue,
+		now:      time.Now,
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a call may be sent. It fails immediately when the queue is full or when the
+// wait would outlast the context deadline, and when the context ends while waiting.
+// A nil limiter never waits.
+func (l *OutboundLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return nil
+	}
+
+	if l.queued >= l.maxQueue {
+		l.mu.Unlock()
+		metrics.Inc(metrics.OutboundShed, "queue_full")
+		return apperrors.NewOutboundShedError(apperrors.ErrCodeOutboundQueueFull, "too many requests waiting for the verification provider")
+	}
+
+	// Reserve the next token; the bucket goes negative for the calls queued ahead.
+	wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.mu.Unlock()
+		metrics.Inc(metrics.OutboundShed, "deadline")
+		return apperrors.NewOutboundShedError(apperrors.ErrCodeOutboundTimeout, "verification provider quota exhausted")
+	}
+	l.tokens--
+	l.queued++
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back so the calls queued behind are not delayed for nothing.
+		l.mu.Lock()
+		l.queued--
+		l.tokens++
+		l.mu.Unlock()
+		metrics.Inc(metrics.OutboundShed, "deadline")
+		return apperrors.NewOutboundShedError(apperrors.ErrCodeOutboundTimeout, "verification provider quota exhausted")
+	}
+}
+
+// Allow takes a token if one is available right now, without queueing. It serves optional calls,
+// such as hedges, that should only be sent with spare quota. A nil limiter always allows.
+func (l *OutboundLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// isShed reports whether err comes from the outbound limiter rather than from the upstream.
+func isShed(err error) bool {
+	var appErr *apperrors.AppError
+	return errors.As(err, &appErr) &&
+		(appErr.Code == apperrors.ErrCodeOutboundQueueFull || appErr.Code == apperrors.ErrCodeOutboundTimeout)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func shedCode(err error) string {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+func TestOutboundLimiter_Wait(t *testing.T) {
+	limiter := NewOutboundLimiter(LimiterConfig{QPS: 20, Burst: 2, MaxQueue: 1})
+
+	// The burst goes through immediately.
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The next call queues for a token (50ms at 20 QPS) while another is shed.
+	queued := make(chan error, 1)
+	start := time.Now()
+	go func() { queued <- limiter.Wait(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := limiter.Wait(context.Background()); shedCode(err) != apperrors.ErrCodeOutboundQueueFull {
+		t.Errorf("expected %s, got %v", apperrors.ErrCodeOutboundQueueFull, err)
+	}
+	if err := <-queued; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected the queued call to wait for a token, waited %v", elapsed)
+	}
+}
+
+func TestOutboundLimiter_NoLimit(t *testing.T) {
+	for _, qps := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		limiter := NewOutboundLimiter(LimiterConfig{QPS: qps})
+		if limiter != nil {
+			t.Fatalf("expected no limiter for QPS %v", qps)
+		}
+		for i := 0; i < 3; i++ {
+			if err := limiter.Wait(context.Background()); err != nil || !limiter.Allow() {
+				t.Fatalf("expected QPS %v to let every call through, got %v", qps, err)
+			}
+		}
+	}
+}
+
+func TestOutboundLimiter_Deadline(t *testing.T) {
+	limiter := NewOutboundLimiter(LimiterConfig{QPS: 1, Burst: 1})
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A token is a second away, beyond the deadline: the call is shed without waiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	if shedCode(err) != apperrors.ErrCodeOutboundTimeout {
+		t.Errorf("expected %s, got %v", apperrors.ErrCodeOutboundTimeout, err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("expected the call to be shed immediately")
+	}
+	if !IsUpstreamFailure(err) {
+		t.Error("expected a shed call to count as an upstream failure")
+	}
+}
+
+func TestRecaptchaService_Assess_OutboundLimiter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true, "action": "login"}, "riskAnalysis": {"score": 0.9}}`))
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(ProviderEnterprise, BreakerConfig{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, Cooldown: time.Minute})
+	svc := NewRecaptchaService("api-key", "site-key", server.URL,
+		WithOutboundLimiter(NewOutboundLimiter(LimiterConfig{QPS: 0.1, Burst: 1})),
+		WithCircuitBreaker(breaker),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+	)
+
+	if _, err := svc.Assess(context.Background(), "token-1", "login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := svc.Assess(ctx, "token-2", "login"); shedCode(err) != apperrors.ErrCodeOutboundTimeout {
+		t.Fatalf("expected the second call to be shed, got %v", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("expected shed calls not to reach upstream nor be retried, got %d calls", calls.Load())
+	}
+	if breaker.State() != BreakerClosed {
+		t.Errorf("expected shed calls not to open the circuit, got %s", breaker.State())
+	}
+}
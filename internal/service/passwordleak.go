@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/passwordleak"
+)
+
+const (
+	maxUsernameLength = 256
+	maxPasswordLength = 1024
+)
+
+// PasswordLeakChecker reports whether a username/password pair appears in a known data breach.
+type PasswordLeakChecker interface {
+	CheckPasswordLeak(ctx context.Context, username, password string) (bool, error)
+}
+
+type passwordLeakRequest struct {
+	PrivatePasswordLeakVerification passwordLeakVerification `json:"privatePasswordLeakVerification"`
+}
+
+type passwordLeakVerification struct {
+	LookupHashPrefix               []byte   `json:"lookupHashPrefix,omitempty"`
+	EncryptedUserCredentialsHash   []byte   `json:"encryptedUserCredentialsHash,omitempty"`
+	EncryptedLeakMatchPrefixes     [][]byte `json:"encryptedLeakMatchPrefixes,omitempty"`
+	ReencryptedUserCredentialsHash []byte   `json:"reencryptedUserCredentialsHash,omitempty"`
+}
+
+type passwordLeakResponse struct {
+	PrivatePasswordLeakVerification passwordLeakVerification `json:"privatePasswordLeakVerification"`
+}
+
+// CheckPasswordLeak runs a private password leak verification. Only a 26-bit prefix of the
+// hashed username and the encrypted credentials hash are sent; the match is decided locally.
+func (s *RecaptchaService) CheckPasswordLeak(ctx context.Context, username, password string) (bool, error) {
+	if err := validateCredentials(username, password); err != nil {
+		return false, err
+	}
+
+	verification, err := passwordleak.NewVerification(username, password)
+	if err != nil {
+		logger.Log.Error("failed to prepare password leak verification", "error", err)
+		return false, apperrors.NewInternalError("failed to prepare password leak verification", err)
+	}
+
+	respBody, err := s.post(ctx, s.endpoint, passwordLeakRequest{
+		PrivatePasswordLeakVerification: passwordLeakVerification{
+			LookupHashPrefix:             verification.LookupHashPrefix,
+			EncryptedUserCredentialsHash: verification.EncryptedUserCredentialsHash,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var resp passwordLeakResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		logger.Log.Error("failed to decode password leak response", "error", err)
+		return false, apperrors.NewInternalError("failed to parse reCAPTCHA response", err)
+	}
+
+	leaked, err := verification.Leaked(
+		resp.PrivatePasswordLeakVerification.ReencryptedUserCredentialsHash,
+		resp.PrivatePasswordLeakVerification.EncryptedLeakMatchPrefixes,
+	)
+	if err != nil {
+		logger.Log.Error("invalid password leak verification response", "error", err)
+		return false, apperrors.NewRecaptchaError("invalid password leak verification response", err)
+	}
+
+	return leaked, nil
+}
+
+// validateCredentials performs the basic checks on credentials before hashing them.
+func validateCredentials(username, password string) error {
+	if strings.TrimSpace(username) == "" || password == "" {
+		return apperrors.NewValidationError("username and password are required", nil)
+	}
+	if len(username) > maxUsernameLength {
+		return apperrors.NewValidationError("username too long", nil)
+	}
+	if len(password) > maxPasswordLength {
+		return apperrors.NewValidationError("password too long", nil)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/passwordleak"
+)
+
+// newLeakServer simulates the Enterprise side of the protocol with a database holding one leaked credential.
+func newLeakServer(t *testing.T, username, password string) *httptest.Server {
+	t.Helper()
+
+	server, err := passwordleak.NewCipher()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	canonical := passwordleak.CanonicalizeUsername(username)
+	hash, err := passwordleak.HashCredentials(canonical, password)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	leak := sha256.Sum256(server.Encrypt(hash))
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req passwordLeakRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+
+		reencrypted, err := server.ReEncrypt(req.PrivatePasswordLeakVerification.EncryptedUserCredentialsHash)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(passwordLeakResponse{
+			PrivatePasswordLeakVerification: passwordLeakVerification{
+				LookupHashPrefix:               req.PrivatePasswordLeakVerification.LookupHashPrefix,
+				ReencryptedUserCredentialsHash: reencrypted,
+				EncryptedLeakMatchPrefixes:     [][]byte{leak[:20]},
+			},
+		})
+	}))
+}
+
+func TestRecaptchaService_CheckPasswordLeak(t *testing.T) {
+	server := newLeakServer(t, "leaked.user@example.com", "password123")
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL)
+
+	testCases := []struct {
+		name     string
+		username string
+		password string
+		leaked   bool
+	}{
+		{"leaked credential", "Leaked.User@example.com", "password123", true},
+		{"different password", "leaked.user@example.com", "s3cure-and-unique", false},
+		{"different user", "someone@example.com", "password123", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			leaked, err := svc.CheckPasswordLeak(context.Background(), tc.username, tc.password)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if leaked != tc.leaked {
+				t.Errorf("expected leaked %v, got %v", tc.leaked, leaked)
+			}
+		})
+	}
+}
+
+func TestRecaptchaService_CheckPasswordLeak_Validation(t *testing.T) {
+	svc := NewRecaptchaService("api-key", "site-key", "http://127.0.0.1:0")
+
+	_, err := svc.CheckPasswordLeak(context.Background(), "user", "")
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeValidationFailed {
+		t.Fatalf("expected %s, got %v", apperrors.ErrCodeValidationFailed, err)
+	}
+}
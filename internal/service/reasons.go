@@ -0,0 +1,88 @@
+package service
+
+import (
+	"net/http"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+// InvalidReason explains why a token was rejected. Values follow the reCAPTCHA Enterprise
+// TokenProperties.InvalidReason enum; other providers are mapped onto them.
+type InvalidReason string
+
+const (
+	InvalidReasonUnspecified  InvalidReason = "INVALID_REASON_UNSPECIFIED"
+	InvalidReasonUnknown      InvalidReason = "UNKNOWN_INVALID_REASON"
+	InvalidReasonMalformed    InvalidReason = "MALFORMED"
+	InvalidReasonExpired      InvalidReason = "EXPIRED"
+	InvalidReasonDupe         InvalidReason = "DUPE"
+	InvalidReasonSiteMismatch InvalidReason = "SITE_MISMATCH"
+	InvalidReasonMissing      InvalidReason = "MISSING"
+	InvalidReasonBrowserError InvalidReason = "BROWSER_ERROR"
+
+	// InvalidReasonActionMismatch is set locally when the token action differs from the expected one.
+	InvalidReasonActionMismatch InvalidReason = "ACTION_MISMATCH"
+
+	// Reasons set locally when a valid token breaks the TokenConstraints of its site key.
+	InvalidReasonStale                  InvalidReason = "STALE"
+	InvalidReasonHostnameMismatch       InvalidReason = "HOSTNAME_MISMATCH"
+	InvalidReasonAndroidPackageMismatch InvalidReason = "ANDROID_PACKAGE_MISMATCH"
+	InvalidReasonIOSBundleMismatch      InvalidReason = "IOS_BUNDLE_MISMATCH"
+)
+
+// RiskReason is a reason contributing to the risk score, as in the reCAPTCHA Enterprise
+// RiskAnalysis.ClassificationReason enum.
+type RiskReason string
+
+const (
+	RiskReasonUnspecified             RiskReason = "CLASSIFICATION_REASON_UNSPECIFIED"
+	RiskReasonAutomation              RiskReason = "AUTOMATION"
+	RiskReasonUnexpectedEnvironment   RiskReason = "UNEXPECTED_ENVIRONMENT"
+	RiskReasonTooMuchTraffic          RiskReason = "TOO_MUCH_TRAFFIC"
+	RiskReasonUnexpectedUsagePatterns RiskReason = "UNEXPECTED_USAGE_PATTERNS"
+	RiskReasonLowConfidenceScore      RiskReason = "LOW_CONFIDENCE_SCORE"
+	RiskReasonSuspectedCarding        RiskReason = "SUSPECTED_CARDING"
+	RiskReasonSuspectedChargeback     RiskReason = "SUSPECTED_CHARGEBACK"
+)
+
+// AccountDefenderLabel is a label from the reCAPTCHA Enterprise
+// AccountDefenderAssessment.AccountDefenderLabel enum.
+type AccountDefenderLabel string
+
+const (
+	AccountDefenderLabelUnspecified               AccountDefenderLabel = "ACCOUNT_DEFENDER_LABEL_UNSPECIFIED"
+	AccountDefenderLabelProfileMatch              AccountDefenderLabel = "PROFILE_MATCH"
+	AccountDefenderLabelSuspiciousLoginActivity   AccountDefenderLabel = "SUSPICIOUS_LOGIN_ACTIVITY"
+	AccountDefenderLabelSuspiciousAccountCreation AccountDefenderLabel = "SUSPICIOUS_ACCOUNT_CREATION"
+	AccountDefenderLabelRelatedAccountsNumberHigh AccountDefenderLabel = "RELATED_ACCOUNTS_NUMBER_HIGH"
+)
+
+// AppError returns the error reported for an invalid token in strict mode.
+// Malformed or missing tokens are client mistakes (400), duplicates conflict with an
+// earlier use (409) and every other rejection is a refusal (403).
+func (r InvalidReason) AppError() *apperrors.AppError {
+	switch r {
+	case InvalidReasonMalformed:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenMalformed, "token is malformed", http.StatusBadRequest)
+	case InvalidReasonMissing:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenMissing, "token is missing", http.StatusBadRequest)
+	case InvalidReasonExpired:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenExpired, "token has expired", http.StatusForbidden)
+	case InvalidReasonDupe:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenDuplicate, "token has already been used", http.StatusConflict)
+	case InvalidReasonSiteMismatch:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenSiteMismatch, "token was issued for a different site", http.StatusForbidden)
+	case InvalidReasonBrowserError:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenBrowserError, "token was generated with a browser error", http.StatusForbidden)
+	case InvalidReasonActionMismatch:
+		return apperrors.NewTokenError(apperrors.ErrCodeActionMismatch, "token was issued for a different action", http.StatusForbidden)
+	case InvalidReasonStale:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenStale, "token is too old", http.StatusForbidden)
+	case InvalidReasonHostnameMismatch:
+		return apperrors.NewTokenError(apperrors.ErrCodeHostnameMismatch, "token was issued for a hostname that is not allowed", http.StatusForbidden)
+	case InvalidReasonAndroidPackageMismatch, InvalidReasonIOSBundleMismatch:
+		return apperrors.NewTokenError(apperrors.ErrCodeAppMismatch, "token was issued for an app that is not allowed", http.StatusForbidden)
+	default:
+		return apperrors.NewTokenError(apperrors.ErrCodeTokenInvalid, "token is invalid", http.StatusForbidden)
+	}
+}
@@ -16,6 +16,16 @@ import (
 
 const (
 	maxErrorBodyBytes = 1024
+	maxTokenLength    = 2000
+	maxActionLength   = 100
+)
+
+// Provider names used to select an Assessor implementation.
+const (
+	ProviderEnterprise = "enterprise"
+	ProviderSiteVerify = "siteverify"
+	ProviderHCaptcha   = "hcaptcha"
+	ProviderTurnstile  = "turnstile"
 )
 
 // Assessor defines the interface for reCAPTCHA assessment.
@@ -23,14 +33,29 @@ type Assessor interface {
 	Assess(ctx context.Context, token, action string) (AssessmentResult, error)
 }
 
+// ExpressAssessor scores requests without a token, from client signals only.
+type ExpressAssessor interface {
+	AssessExpress(ctx context.Context, action string) (AssessmentResult, error)
+}
+
 // AssessmentResult exposes the relevant information returned by the reCAPTCHA Enterprise API.
 type AssessmentResult struct {
-	Valid         bool      `json:"valid"`
-	Score         float64   `json:"score,omitempty"`
-	Action        string    `json:"action,omitempty"`
-	InvalidReason string    `json:"invalidReason,omitempty"`
-	Reasons       []string  `json:"reasons,omitempty"`
-	CreateTime    time.Time `json:"createTime,omitempty"`
+	Name                  string                     `json:"name,omitempty"`
+	Valid                 bool                       `json:"valid"`
+	Score                 float64                    `json:"score,omitempty"`
+	Action                string                     `json:"action,omitempty"`
+	Hostname              string                     `json:"hostname,omitempty"`
+	AndroidPackageName    string                     `json:"androidPackageName,omitempty"`
+	IOSBundleID           string                     `json:"iosBundleId,omitempty"`
+	InvalidReason         InvalidReason              `json:"invalidReason,omitempty"`
+	Reasons               []RiskReason               `json:"reasons,omitempty"`
+	CreateTime            time.Time                  `json:"createTime,omitempty"`
+	AccountDefenderLabels []AccountDefenderLabel     `json:"accountDefenderLabels,omitempty"`
+	FraudPrevention       *FraudPreventionAssessment `json:"fraudPrevention,omitempty"`
+	Provider              string                     `json:"provider,omitempty"`
+	Site                  string                     `json:"site,omitempty"`
+	Degraded              bool                       `json:"degraded,omitempty"`
+	Cached                bool                       `json:"cached,omitempty"`
 }
 
 type assessmentRequest struct {
@@ -38,126 +63,483 @@ type assessmentRequest struct {
 }
 
 type assessmentEvent struct {
-	Token          string `json:"token"`
-	SiteKey        string `json:"siteKey"`
-	ExpectedAction string `json:"expectedAction,omitempty"`
+	Token           string           `json:"token,omitempty"`
+	SiteKey         string           `json:"siteKey"`
+	ExpectedAction  string           `json:"expectedAction,omitempty"`
+	HashedAccountID []byte           `json:"hashedAccountId,omitempty"`
+	UserInfo        *userInfo        `json:"userInfo,omitempty"`
+	TransactionData *TransactionData `json:"transactionData,omitempty"`
+	UserIPAddress   string           `json:"userIpAddress,omitempty"`
+	UserAgent       string           `json:"userAgent,omitempty"`
+	JA3             string           `json:"ja3,omitempty"`
+	Headers         []string         `json:"headers,omitempty"`
+	Express         bool             `json:"express,omitempty"`
+}
+
+type userInfo struct {
+	AccountID string `json:"accountId,omitempty"`
 }
 
 type assessmentResponse struct {
+	Name            string `json:"name"`
 	TokenProperties struct {
-		Valid         bool      `json:"valid"`
-		Action        string    `json:"action"`
-		InvalidReason string    `json:"invalidReason"`
-		CreateTime    time.Time `json:"createTime"`
+		Valid              bool          `json:"valid"`
+		Action             string        `json:"action"`
+		Hostname           string        `json:"hostname"`
+		AndroidPackageName string        `json:"androidPackageName"`
+		IOSBundleID        string        `json:"iosBundleId"`
+		InvalidReason      InvalidReason `json:"invalidReason"`
+		CreateTime         time.Time     `json:"createTime"`
 	} `json:"tokenProperties"`
 	RiskAnalysis struct {
-		Score   float64  `json:"score"`
-		Reasons []string `json:"reasons"`
+		Score   float64      `json:"score"`
+		Reasons []RiskReason `json:"reasons"`
 	} `json:"riskAnalysis"`
+	AccountDefenderAssessment struct {
+		Labels []AccountDefenderLabel `json:"labels"`
+	} `json:"accountDefenderAssessment"`
+	FraudPreventionAssessment *fraudPreventionResponse `json:"fraudPreventionAssessment"`
+}
+
+// EnterpriseAssessmentsEndpoint returns the assessments URL of a Google Cloud project.
+func EnterpriseAssessmentsEndpoint(projectID string) string {
+	return "https://recaptchaenterprise.googleapis.com/v1/projects/" + projectID + "/assessments"
 }
 
 // RecaptchaService coordinates the interaction with the reCAPTCHA Enterprise API.
 type RecaptchaService struct {
-	client   *http.Client
-	apiKey   string
-	siteKey  string
-	endpoint string
+	client         *http.Client
+	apiKey         string
+	tokens         TokenSource
+	siteKey        string
+	endpoint       string
+	breaker        *CircuitBreaker
+	retry          RetryPolicy
+	constraints    TokenConstraints
+	expressSiteKey string
+	accountSalt    []byte
+	hedge          *hedger
+	limiter        *OutboundLimiter
+	now            func() time.Time
+}
+
+// Option customizes a RecaptchaService.
+type Option func(*RecaptchaService)
+
+// WithCircuitBreaker makes upstream calls fail fast while the breaker is open.
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(s *RecaptchaService) {
+		s.breaker = breaker
+	}
+}
+
+// WithRetryPolicy retries transient upstream failures with exponential backoff and jitter.
+// Unset fields of policy take the package defaults.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *RecaptchaService) {
+		s.retry = policy.withDefaults()
+	}
+}
+
+// WithTokenConstraints rejects valid tokens that are too old or come from a hostname,
+// Android package or iOS bundle outside the allow-lists of the site key.
+func WithTokenConstraints(constraints TokenConstraints) Option {
+	return func(s *RecaptchaService) {
+		s.constraints = constraints
+	}
+}
+
+// WithAccountDefender sends the account identifier of each event to Account Defender,
+// hashed with salt. Without it, account identifiers are not sent.
+func WithAccountDefender(salt string) Option {
+	return func(s *RecaptchaService) {
+		s.accountSalt = []byte(salt)
+	}
+}
+
+// WithExpressSiteKey sets the express site key used by AssessExpress. It defaults to the site key.
+func WithExpressSiteKey(siteKey string) Option {
+	return func(s *RecaptchaService) {
+		s.expressSiteKey = siteKey
+	}
+}
+
+// WithHedging sends a second, concurrent assessment call when the first one is slower than
+// the configured percentile of recent calls, and keeps whichever answers first.
+func WithHedging(policy HedgePolicy) Option {
+	return func(s *RecaptchaService) {
+		s.hedge = newHedger(policy)
+	}
+}
+
+// WithOutboundLimiter makes every upstream call wait for a token of limiter. Share one limiter
+// between services calling the same quota.
+func WithOutboundLimiter(limiter *OutboundLimiter) Option {
+	return func(s *RecaptchaService) {
+		s.limiter = limiter
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, for example with one from NewHTTPClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *RecaptchaService) {
+		s.client = client
+	}
+}
+
+// WithTokenSource authenticates with OAuth2 access tokens from tokens, sent as
+// Authorization: Bearer, instead of the API key.
+func WithTokenSource(tokens TokenSource) Option {
+	return func(s *RecaptchaService) {
+		s.tokens = tokens
+	}
+}
+
+// upstreamResponse is the raw outcome of a single call to the Enterprise API.
+type upstreamResponse struct {
+	status     int
+	body       []byte
+	retryAfter time.Duration
 }
 
 // NewRecaptchaService builds a RecaptchaService with sane defaults.
-func NewRecaptchaService(apiKey, siteKey, endpoint string) *RecaptchaService {
-	return &RecaptchaService{
-		client:   &http.Client{Timeout: 10 * time.Second},
-		apiKey:   apiKey,
-		siteKey:  siteKey,
-		endpoint: endpoint,
+func NewRecaptchaService(apiKey, siteKey, endpoint string, opts ...Option) *RecaptchaService {
+	s := &RecaptchaService{
+		client:         &http.Client{Timeout: DefaultOutboundTimeout},
+		apiKey:         apiKey,
+		siteKey:        siteKey,
+		expressSiteKey: siteKey,
+		endpoint:       endpoint,
+		retry:          RetryPolicy{MaxAttempts: 1},
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// CircuitBreaker returns the breaker guarding upstream calls, or nil when none is configured.
+func (s *RecaptchaService) CircuitBreaker() *CircuitBreaker {
+	return s.breaker
 }
 
 // Assess validates the provided token and returns the assessment outcome.
 func (s *RecaptchaService) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
-	if strings.TrimSpace(token) == "" {
-		return AssessmentResult{}, apperrors.NewValidationError("token is required", nil)
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
 	}
 
-	// Validate token format (basic check)
-	if len(token) > 2000 {
-		return AssessmentResult{}, apperrors.NewValidationError("token too long", nil)
+	details := EventDetailsFromContext(ctx)
+	if err := details.validate(); err != nil {
+		return AssessmentResult{}, err
 	}
 
-	// Validate action length
-	if len(action) > 100 {
+	event := s.newEvent(s.siteKey, action, details)
+	event.Token = token
+
+	return s.createAssessment(ctx, event)
+}
+
+// AssessExpress scores a request without a token, from the client signals attached to ctx
+// (see ContextWithEventDetails). The client IP address is required.
+func (s *RecaptchaService) AssessExpress(ctx context.Context, action string) (AssessmentResult, error) {
+	if len(action) > maxActionLength {
 		return AssessmentResult{}, apperrors.NewValidationError("action name too long", nil)
 	}
 
-	payload := assessmentRequest{
-		Event: assessmentEvent{
-			Token:   token,
-			SiteKey: s.siteKey,
-		},
+	details := EventDetailsFromContext(ctx)
+	if err := details.validate(); err != nil {
+		return AssessmentResult{}, err
+	}
+	if details.UserIPAddress == "" {
+		return AssessmentResult{}, apperrors.NewValidationError("express assessments require the client IP address", nil)
+	}
+
+	event := s.newEvent(s.expressSiteKey, action, details)
+	event.Express = true
+
+	return s.createAssessment(ctx, event)
+}
+
+// newEvent builds the assessment event shared by token and express assessments.
+func (s *RecaptchaService) newEvent(siteKey, action string, details EventDetails) assessmentEvent {
+	event := assessmentEvent{
+		SiteKey:         siteKey,
+		ExpectedAction:  strings.TrimSpace(action),
+		TransactionData: details.Transaction,
+		UserIPAddress:   details.UserIPAddress,
+		UserAgent:       details.UserAgent,
+		JA3:             details.JA3,
+		Headers:         details.Headers,
+	}
+
+	if strings.TrimSpace(details.AccountID) != "" {
+		if len(s.accountSalt) > 0 {
+			event.HashedAccountID, event.UserInfo = accountFields(s.accountSalt, details.AccountID)
+		} else {
+			logger.Log.Warn("account identifier ignored, Account Defender is not configured")
+		}
+	}
+
+	return event
+}
+
+// createAssessment sends the event to Enterprise and converts the response into a result.
+// Express assessments carry no token, so they are reported valid and only scored.
+func (s *RecaptchaService) createAssessment(ctx context.Context, event assessmentEvent) (AssessmentResult, error) {
+	respBody, err := s.send(ctx, s.endpoint, assessmentRequest{Event: event}, s.doHedged)
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+
+	var assessment assessmentResponse
+	if err := json.Unmarshal(respBody, &assessment); err != nil {
+		logger.Log.Error("failed to decode assessment response", "error", err, "body", string(respBody))
+		return AssessmentResult{}, apperrors.NewInternalError("failed to parse reCAPTCHA response", err)
+	}
+
+	result := AssessmentResult{
+		Name:                  assessment.Name,
+		Valid:                 assessment.TokenProperties.Valid,
+		Action:                assessment.TokenProperties.Action,
+		Hostname:              assessment.TokenProperties.Hostname,
+		AndroidPackageName:    assessment.TokenProperties.AndroidPackageName,
+		IOSBundleID:           assessment.TokenProperties.IOSBundleID,
+		InvalidReason:         assessment.TokenProperties.InvalidReason,
+		Score:                 assessment.RiskAnalysis.Score,
+		Reasons:               assessment.RiskAnalysis.Reasons,
+		CreateTime:            assessment.TokenProperties.CreateTime,
+		Provider:              ProviderEnterprise,
+		AccountDefenderLabels: assessment.AccountDefenderAssessment.Labels,
+		FraudPrevention:       assessment.FraudPreventionAssessment.toAssessment(),
+	}
+
+	if event.Express {
+		result.Valid = true
+		result.Action = event.ExpectedAction
+		return result, nil
 	}
 
-	if trimmedAction := strings.TrimSpace(action); trimmedAction != "" {
-		payload.Event.ExpectedAction = trimmedAction
+	if result.Valid {
+		if reason := s.constraints.check(result, s.now()); reason != "" {
+			logger.Log.Warn("token rejected by site key constraints",
+				"reason", reason,
+				"hostname", result.Hostname,
+				"android_package", result.AndroidPackageName,
+				"ios_bundle", result.IOSBundleID,
+				"create_time", result.CreateTime,
+			)
+			result.Valid = false
+			result.InvalidReason = reason
+		}
 	}
 
+	return result, nil
+}
+
+// post sends payload to the Enterprise API and returns the body of a successful response.
+// Transient failures are retried according to the retry policy, within the context deadline.
+// Calls are rejected without touching the network while the circuit breaker is open.
+func (s *RecaptchaService) post(ctx context.Context, url string, payload any) ([]byte, error) {
+	return s.send(ctx, url, payload, s.doLimited)
+}
+
+// send is post with the function performing each call.
+func (s *RecaptchaService) send(ctx context.Context, url string, payload any, call func(context.Context, string, []byte) (upstreamResponse, error)) ([]byte, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		logger.Log.Error("failed to marshal assessment request", "error", err)
-		return AssessmentResult{}, apperrors.NewInternalError("failed to prepare request", err)
+		return nil, apperrors.NewInternalError("failed to prepare request", err)
+	}
+
+	callerCtx := ctx
+	if s.retry.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.retry.Budget)
+		defer cancel()
 	}
 
+	var (
+		resp    upstreamResponse
+		attempt int
+		// unanswered is set once an attempt may have reached Enterprise without its answer
+		// reaching us, e.g. a timeout or a 5xx. Assessments are not idempotent, so such an
+		// attempt may already have consumed the token.
+		unanswered bool
+	)
+	for attempt = 1; ; attempt++ {
+		if s.breaker != nil && !s.breaker.Allow() {
+			logger.Log.Warn("reCAPTCHA Enterprise circuit open, failing fast", "attempt", attempt)
+			return nil, apperrors.NewCircuitOpenError("reCAPTCHA service temporarily unavailable", nil)
+		}
+
+		resp, err = call(ctx, url, body)
+		s.recordOutcome(callerCtx, resp, err)
+		if (err != nil && !isShed(err)) || resp.status >= 500 {
+			unanswered = true
+		}
+
+		if attempt >= s.retry.MaxAttempts || !isRetriable(resp, err) || callerCtx.Err() != nil {
+			break
+		}
+
+		wait := s.retry.delay(attempt, resp.retryAfter)
+		if !fitsDeadline(ctx, wait) {
+			logger.Log.Warn("no time left to retry reCAPTCHA Enterprise call", "attempt", attempt, "wait", wait.String())
+			break
+		}
+
+		logger.Log.Warn("retrying reCAPTCHA Enterprise call",
+			"attempt", attempt,
+			"status", resp.status,
+			"wait", wait.String(),
+		)
+		if sleepContext(ctx, wait) != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		logger.Log.Error("reCAPTCHA Enterprise call failed", "attempts", attempt, "error", err)
+		return nil, err
+	}
+
+	if resp.status != http.StatusOK {
+		trimmed := trimErrorBody(resp.body)
+		logger.Log.Error("reCAPTCHA Enterprise returned error",
+			"status", resp.status,
+			"attempts", attempt,
+			"body", trimmed,
+		)
+		return nil, apperrors.NewRecaptchaError(
+			"reCAPTCHA verification failed",
+			fmt.Errorf("status %d after %d attempt(s): %s", resp.status, attempt, trimmed),
+		)
+	}
+
+	if unanswered && isDupeResponse(resp.body) {
+		// The DUPE is most likely the doing of the earlier attempt, not of the client.
+		logger.Log.Error("reCAPTCHA Enterprise reported DUPE after a failed attempt", "attempts", attempt)
+		return nil, apperrors.NewRecaptchaError(
+			"reCAPTCHA verification failed",
+			fmt.Errorf("DUPE after %d attempt(s), an earlier attempt may have consumed the token", attempt),
+		)
+	}
+
+	if attempt > 1 {
+		logger.Log.Info("reCAPTCHA Enterprise call succeeded after retry", "attempts", attempt)
+	}
+
+	return resp.body, nil
+}
+
+// acquire waits for the outbound limiter, when configured, to let a call through.
+func (s *RecaptchaService) acquire(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	if err := s.limiter.Wait(ctx); err != nil {
+		logger.Log.Warn("reCAPTCHA Enterprise call shed by the outbound limiter", "error", err)
+		return err
+	}
+	return nil
+}
+
+// doLimited performs a single HTTP call once the outbound limiter lets it through.
+func (s *RecaptchaService) doLimited(ctx context.Context, url string, body []byte) (upstreamResponse, error) {
+	if err := s.acquire(ctx); err != nil {
+		return upstreamResponse{}, err
+	}
+	return s.do(ctx, url, body)
+}
+
+// do performs a single HTTP call. Only transport and read failures are returned as errors.
+func (s *RecaptchaService) do(ctx context.Context, url string, body []byte) (upstreamResponse, error) {
 	// Use API key in header instead of query param for better security
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		logger.Log.Error("failed to create assessment request", "error", err)
-		return AssessmentResult{}, apperrors.NewInternalError("failed to create request", err)
+		return upstreamResponse{}, apperrors.NewInternalError("failed to create request", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-goog-api-key", s.apiKey)
+	if s.tokens != nil {
+		token, err := s.tokens.Token(ctx)
+		if err != nil {
+			return upstreamResponse{}, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("X-goog-api-key", s.apiKey)
+	}
 
 	resp, err := s.client.Do(req)
+	if err != nil && ctx.Err() != nil {
+		// Canceled by the caller or by a hedged call that answered first.
+		logger.Log.Debug("request to reCAPTCHA Enterprise canceled", "error", err)
+		return upstreamResponse{}, apperrors.NewRecaptchaError("failed to connect to reCAPTCHA service", err)
+	}
 	if err != nil {
 		logger.Log.Error("request to reCAPTCHA Enterprise failed", "error", err)
-		return AssessmentResult{}, apperrors.NewRecaptchaError("failed to connect to reCAPTCHA service", err)
+		return upstreamResponse{}, apperrors.NewRecaptchaError("failed to connect to reCAPTCHA service", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		logger.Log.Error("failed to read assessment response", "error", err)
-		return AssessmentResult{}, apperrors.NewRecaptchaError("failed to read reCAPTCHA response", err)
+		return upstreamResponse{}, apperrors.NewRecaptchaError("failed to read reCAPTCHA response", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		trimmed := string(respBody)
-		if len(trimmed) > maxErrorBodyBytes {
-			trimmed = trimmed[:maxErrorBodyBytes]
-		}
-		logger.Log.Error("reCAPTCHA Enterprise returned error",
-			"status", resp.StatusCode,
-			"body", trimmed,
-		)
-		return AssessmentResult{}, apperrors.NewRecaptchaError(
-			"reCAPTCHA verification failed",
-			fmt.Errorf("status %d: %s", resp.StatusCode, trimmed),
-		)
+	return upstreamResponse{
+		status:     resp.StatusCode,
+		body:       respBody,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}, nil
+}
+
+// recordOutcome feeds the circuit breaker. Transport errors, 429 and 5xx responses count as
+// failures; other responses prove the upstream is reachable. Calls canceled by the caller or shed
+// by the outbound limiter are ignored.
+func (s *RecaptchaService) recordOutcome(ctx context.Context, resp upstreamResponse, err error) {
+	if s.breaker == nil {
+		return
 	}
 
-	var assessment assessmentResponse
-	if err := json.Unmarshal(respBody, &assessment); err != nil {
-		logger.Log.Error("failed to decode assessment response", "error", err, "body", string(respBody))
-		return AssessmentResult{}, apperrors.NewInternalError("failed to parse reCAPTCHA response", err)
+	switch {
+	case err != nil && (ctx.Err() != nil || isShed(err)):
+		s.breaker.Discard()
+	case err != nil:
+		s.breaker.Record(false)
+	default:
+		s.breaker.Record(resp.status != http.StatusTooManyRequests && resp.status < 500)
 	}
+}
 
-	result := AssessmentResult{
-		Valid:         assessment.TokenProperties.Valid,
-		Action:        assessment.TokenProperties.Action,
-		InvalidReason: assessment.TokenProperties.InvalidReason,
-		Score:         assessment.RiskAnalysis.Score,
-		Reasons:       assessment.RiskAnalysis.Reasons,
-		CreateTime:    assessment.TokenProperties.CreateTime,
+// validateTokenInput performs the basic checks shared by every provider before calling upstream.
+func validateTokenInput(token, action string) error {
+	if strings.TrimSpace(token) == "" {
+		return apperrors.NewValidationError("token is required", nil)
 	}
 
-	return result, nil
+	// Validate token format (basic check)
+	if len(token) > maxTokenLength {
+		return apperrors.NewValidationError("token too long", nil)
+	}
+
+	// Validate action length
+	if len(action) > maxActionLength {
+		return apperrors.NewValidationError("action name too long", nil)
+	}
+
+	return nil
+}
+
+// trimErrorBody limits the upstream error body kept in logs and errors.
+func trimErrorBody(body []byte) string {
+	trimmed := string(body)
+	if len(trimmed) > maxErrorBodyBytes {
+		trimmed = trimmed[:maxErrorBodyBytes]
+	}
+	return trimmed
 }
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func TestRecaptchaService_Assess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-goog-api-key") != "api-key" {
+			t.Errorf("expected API key header, got %q", r.Header.Get("X-goog-api-key"))
+		}
+		_, _ = w.Write([]byte(`{
+			"tokenProperties": {"valid": true, "action": "login", "createTime": "2026-01-15T10:00:00Z"},
+			"riskAnalysis": {"score": 0.9, "reasons": []}
+		}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL)
+	result, err := svc.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !result.Valid || result.Score != 0.9 || result.Provider != ProviderEnterprise {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRecaptchaService_Assess_CircuitOpen(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(ProviderEnterprise, BreakerConfig{FailureRatio: 1, MinRequests: 2})
+	svc := NewRecaptchaService("api-key", "site-key", server.URL, WithCircuitBreaker(breaker))
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Assess(context.Background(), "token", "login")
+		var appErr *apperrors.AppError
+		if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeRecaptchaFailed {
+			t.Fatalf("expected %s, got %v", apperrors.ErrCodeRecaptchaFailed, err)
+		}
+	}
+
+	_, err := svc.Assess(context.Background(), "token", "login")
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeCircuitOpen {
+		t.Fatalf("expected %s, got %v", apperrors.ErrCodeCircuitOpen, err)
+	}
+	if appErr.HTTPStatus != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", appErr.HTTPStatus)
+	}
+	if calls != 2 {
+		t.Errorf("expected the open circuit to skip the upstream call, got %d calls", calls)
+	}
+}
+
+func TestRecaptchaService_Assess_RetriesTransientErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statuses      []int
+		expectedCalls int
+		expectError   bool
+	}{
+		{"503 then success", []int{http.StatusServiceUnavailable, http.StatusOK}, 2, false},
+		{"429 then success", []int{http.StatusTooManyRequests, http.StatusOK}, 2, false},
+		{"400 is not retried", []int{http.StatusBadRequest, http.StatusOK}, 1, true},
+		{"gives up after max attempts", []int{500, 502, 504, http.StatusOK}, 3, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tc.statuses[calls]
+				calls++
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"tokenProperties":{"valid":true},"riskAnalysis":{"score":0.7}}`))
+			}))
+			defer server.Close()
+
+			svc := NewRecaptchaService("api-key", "site-key", server.URL,
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}),
+			)
+			_, err := svc.Assess(context.Background(), "token", "login")
+
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectError, err)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRecaptchaService_Assess_DupeAfterRetry(t *testing.T) {
+	testCases := []struct {
+		name        string
+		first       func(w http.ResponseWriter)
+		expectError bool
+	}{
+		{"timeout then DUPE", func(w http.ResponseWriter) {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"tokenProperties":{"valid":true},"riskAnalysis":{"score":0.9}}`))
+		}, true},
+		{"503 then DUPE", func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }, true},
+		{"429 then DUPE", func(w http.ResponseWriter) { w.WriteHeader(http.StatusTooManyRequests) }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					tc.first(w)
+					return
+				}
+				_, _ = w.Write([]byte(`{"tokenProperties":{"valid":false,"invalidReason":"DUPE"}}`))
+			}))
+			defer server.Close()
+
+			svc := NewRecaptchaService("api-key", "site-key", server.URL,
+				WithHTTPClient(&http.Client{Timeout: 30 * time.Millisecond}),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}),
+			)
+			result, err := svc.Assess(context.Background(), "token", "login")
+
+			if calls.Load() != 2 {
+				t.Fatalf("expected 2 calls, got %d", calls.Load())
+			}
+			if tc.expectError {
+				if !IsUpstreamFailure(err) {
+					t.Errorf("expected an upstream failure instead of the retry's DUPE, got %+v, %v", result, err)
+				}
+				return
+			}
+			if err != nil || result.InvalidReason != InvalidReasonDupe {
+				t.Errorf("expected DUPE, got %+v, %v", result, err)
+			}
+		})
+	}
+}
+
+func TestRecaptchaService_Assess_RetryAfterBeyondDeadline(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := svc.Assess(ctx, "token", "login"); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("expected no retry when Retry-After exceeds the deadline, got %d calls", calls)
+	}
+}
+
+func TestRecaptchaService_Assess_TokenConstraints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"tokenProperties": {"valid": true, "action": "login", "hostname": "evil.com", "createTime": "2026-01-15T10:00:00Z"},
+			"riskAnalysis": {"score": 0.9}
+		}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL,
+		WithTokenConstraints(TokenConstraints{Hostnames: []string{"example.com"}}))
+	svc.now = func() time.Time { return time.Date(2026, 1, 15, 10, 0, 30, 0, time.UTC) }
+
+	result, err := svc.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Valid || result.InvalidReason != InvalidReasonHostnameMismatch {
+		t.Errorf("expected HOSTNAME_MISMATCH, got valid=%v reason=%s", result.Valid, result.InvalidReason)
+	}
+	if result.Hostname != "evil.com" {
+		t.Errorf("expected hostname to be reported, got %q", result.Hostname)
+	}
+}
+
+func TestRecaptchaService_Assess_AccountDefender(t *testing.T) {
+	salt := "server-salt"
+	expected := hashAccountID([]byte(salt), "user-42")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload assessmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !bytes.Equal(payload.Event.HashedAccountID, expected) {
+			t.Errorf("unexpected hashedAccountId %x", payload.Event.HashedAccountID)
+		}
+		if payload.Event.UserInfo == nil || payload.Event.UserInfo.AccountID != hex.EncodeToString(expected) {
+			t.Errorf("unexpected userInfo %+v", payload.Event.UserInfo)
+		}
+		_, _ = w.Write([]byte(`{
+			"tokenProperties": {"valid": true, "action": "login"},
+			"riskAnalysis": {"score": 0.9},
+			"accountDefenderAssessment": {"labels": ["SUSPICIOUS_LOGIN_ACTIVITY"]}
+		}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL, WithAccountDefender(salt))
+	ctx := ContextWithEventDetails(context.Background(), EventDetails{AccountID: "user-42"})
+
+	result, err := svc.Assess(ctx, "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.AccountDefenderLabels) != 1 || result.AccountDefenderLabels[0] != AccountDefenderLabelSuspiciousLoginActivity {
+		t.Errorf("unexpected labels: %v", result.AccountDefenderLabels)
+	}
+}
+
+func TestRecaptchaService_Assess_AccountIDWithoutSalt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload assessmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if payload.Event.HashedAccountID != nil || payload.Event.UserInfo != nil {
+			t.Errorf("expected no account identifier without a salt, got %+v", payload.Event)
+		}
+		_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true}}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL)
+	ctx := ContextWithEventDetails(context.Background(), EventDetails{AccountID: "user-42"})
+
+	if _, err := svc.Assess(ctx, "token", "login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecaptchaService_Assess_TransactionData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload assessmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if payload.Event.TransactionData == nil || payload.Event.TransactionData.Value != 99.9 {
+			t.Errorf("expected transaction data in event, got %+v", payload.Event.TransactionData)
+		}
+		_, _ = w.Write([]byte(`{
+			"tokenProperties": {"valid": true, "action": "checkout"},
+			"riskAnalysis": {"score": 0.8},
+			"fraudPreventionAssessment": {
+				"transactionRisk": 0.6,
+				"stolenInstrumentVerdict": {"risk": 0.4},
+				"cardTestingVerdict": {"risk": 0.1}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL)
+	ctx := ContextWithEventDetails(context.Background(), EventDetails{
+		Transaction: &TransactionData{Value: 99.9, CurrencyCode: "USD", CardLastFour: "4242"},
+	})
+
+	result, err := svc.Assess(ctx, "token", "checkout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := FraudPreventionAssessment{TransactionRisk: 0.6, StolenInstrumentRisk: 0.4, CardTestingRisk: 0.1}
+	if result.FraudPrevention == nil || *result.FraudPrevention != expected {
+		t.Errorf("unexpected fraud prevention assessment: %+v", result.FraudPrevention)
+	}
+}
+
+func TestRecaptchaService_Assess_InvalidTransactionData(t *testing.T) {
+	svc := NewRecaptchaService("api-key", "site-key", "http://127.0.0.1:0")
+
+	testCases := []struct {
+		name        string
+		transaction TransactionData
+	}{
+		{"negative value", TransactionData{Value: -1}},
+		{"bad currency", TransactionData{CurrencyCode: "EURO"}},
+		{"bad card last four", TransactionData{CardLastFour: "42a2"}},
+		{"bad region code", TransactionData{BillingAddress: &TransactionAddress{RegionCode: "ESP"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := ContextWithEventDetails(context.Background(), EventDetails{Transaction: &tc.transaction})
+			_, err := svc.Assess(ctx, "token", "checkout")
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeValidationFailed {
+				t.Fatalf("expected %s, got %v", apperrors.ErrCodeValidationFailed, err)
+			}
+		})
+	}
+}
+
+func TestRecaptchaService_Assess_ClientSignals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload assessmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		event := payload.Event
+		if event.UserIPAddress != "203.0.113.7" || event.UserAgent != "agent" || event.JA3 != "ja3" || len(event.Headers) != 1 {
+			t.Errorf("expected client signals in event, got %+v", event)
+		}
+		_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true}}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL)
+	ctx := ContextWithEventDetails(context.Background(), EventDetails{
+		UserIPAddress: "203.0.113.7",
+		UserAgent:     "agent",
+		JA3:           "ja3",
+		Headers:       []string{"Accept-Language: es-ES"},
+	})
+
+	if _, err := svc.Assess(ctx, "token", "login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx = ContextWithEventDetails(context.Background(), EventDetails{UserIPAddress: "not-an-ip"})
+	_, err := svc.Assess(ctx, "token", "login")
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeValidationFailed {
+		t.Fatalf("expected %s, got %v", apperrors.ErrCodeValidationFailed, err)
+	}
+}
+
+func TestRecaptchaService_AssessExpress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload assessmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		event := payload.Event
+		if !event.Express || event.Token != "" || event.SiteKey != "express-key" || event.UserIPAddress != "203.0.113.7" {
+			t.Errorf("unexpected express event %+v", event)
+		}
+		_, _ = w.Write([]byte(`{"name": "projects/p/assessments/a1", "riskAnalysis": {"score": 0.7}}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("api-key", "site-key", server.URL, WithExpressSiteKey("express-key"))
+	ctx := ContextWithEventDetails(context.Background(), EventDetails{UserIPAddress: "203.0.113.7", UserAgent: "agent"})
+
+	result, err := svc.AssessExpress(ctx, "api_call")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid || result.Score != 0.7 || result.Action != "api_call" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	_, err = svc.AssessExpress(context.Background(), "api_call")
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeValidationFailed {
+		t.Fatalf("expected %s without client IP, got %v", apperrors.ErrCodeValidationFailed, err)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+// ProviderRegistry resolves which Assessor handles a request, either by provider name
+// or by the site key the token was issued for.
+type ProviderRegistry struct {
+	providers       map[string]Assessor
+	siteKeys        map[string]string
+	defaultProvider string
+}
+
+// NewProviderRegistry creates an empty registry that falls back to defaultProvider
+// when a request names neither a provider nor a site key.
+func NewProviderRegistry(defaultProvider string) *ProviderRegistry {
+	return &ProviderRegistry{
+		providers:       make(map[string]Assessor),
+		siteKeys:        make(map[string]string),
+		defaultProvider: defaultProvider,
+	}
+}
+
+// Register adds a provider and the site keys whose tokens it verifies.
+func (r *ProviderRegistry) Register(name string, assessor Assessor, siteKeys ...string) {
+	r.providers[name] = assessor
+	for _, siteKey := range siteKeys {
+		if siteKey != "" {
+			r.siteKeys[siteKey] = name
+		}
+	}
+}
+
+// Wrap replaces every registered Assessor with the result of wrap, e.g. to add a shared guard.
+func (r *ProviderRegistry) Wrap(wrap func(Assessor) Assessor) {
+	for name, assessor := range r.providers {
+		r.providers[name] = wrap(assessor)
+	}
+}
+
+// Lookup returns the Assessor registered under name.
+func (r *ProviderRegistry) Lookup(name string) (Assessor, bool) {
+	assessor, ok := r.providers[name]
+	return assessor, ok
+}
+
+// Providers returns the sorted names of the registered providers.
+func (r *ProviderRegistry) Providers() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the provider name and Assessor for the request.
+// An explicit provider wins; otherwise the site key decides; otherwise the default is used.
+func (r *ProviderRegistry) Resolve(provider, siteKey string) (string, Assessor, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	siteKey = strings.TrimSpace(siteKey)
+
+	if siteKey != "" {
+		bySiteKey, ok := r.siteKeys[siteKey]
+		if !ok {
+			return "", nil, apperrors.NewValidationError("unknown site key", nil)
+		}
+		if provider != "" && provider != bySiteKey {
+			return "", nil, apperrors.NewValidationError(
+				"site key does not belong to the requested provider",
+				fmt.Errorf("site key registered for %s, requested %s", bySiteKey, provider),
+			)
+		}
+		provider = bySiteKey
+	}
+
+	if provider == "" {
+		provider = r.defaultProvider
+	}
+
+	assessor, ok := r.providers[provider]
+	if !ok {
+		return "", nil, apperrors.NewValidationError("unsupported captcha provider", fmt.Errorf("provider %q", provider))
+	}
+
+	return provider, assessor, nil
+}
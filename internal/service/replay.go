@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/metrics"
+)
+
+// DefaultReplayTTL covers the lifetime of reCAPTCHA (2 minutes) and Turnstile/hCaptcha (5 minutes) tokens.
+const DefaultReplayTTL = 5 * time.Minute
+
+// ReplayStore remembers which tokens have already been presented.
+// Implementations must be safe for concurrent use; a shared backend lets
+// several instances reject tokens replayed against any of them.
+type ReplayStore interface {
+	// Claim marks key as used for ttl and reports whether this is its first use.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release forgets key so the token can be presented again.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryReplayStore is an in-process ReplayStore.
+type MemoryReplayStore struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	now       func() time.Time
+	cleanupCh chan struct{}
+}
+
+// NewMemoryReplayStore creates a MemoryReplayStore and starts its cleanup goroutine.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	s := &MemoryReplayStore{
+		entries:   make(map[string]time.Time),
+		now:       time.Now,
+		cleanupCh: make(chan struct{}),
+	}
+
+	go s.cleanup(time.Minute)
+
+	return s
+}
+
+// Claim implements ReplayStore.
+func (s *MemoryReplayStore) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if expiresAt, exists := s.entries[key]; exists && now.Before(expiresAt) {
+		return false, nil
+	}
+
+	s.entries[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements ReplayStore.
+func (s *MemoryReplayStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryReplayStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			now := s.now()
+			for key, expiresAt := range s.entries {
+				if !now.Before(expiresAt) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.cleanupCh:
+			return
+		}
+	}
+}
+
+// Stop stops the cleanup goroutine.
+func (s *MemoryReplayStore) Stop() {
+	close(s.cleanupCh)
+}
+
+// ReplayGuard rejects tokens that were already presented within their lifetime
+// before spending an upstream assessment on them.
+type ReplayGuard struct {
+	next  Assessor
+	store ReplayStore
+	ttl   time.Duration
+}
+
+// NewReplayGuard wraps next with one-time-use protection backed by store.
+func NewReplayGuard(next Assessor, store ReplayStore, ttl time.Duration) *ReplayGuard {
+	if ttl <= 0 {
+		ttl = DefaultReplayTTL
+	}
+	return &ReplayGuard{next: next, store: store, ttl: ttl}
+}
+
+// Assess reports a reused token as invalid with reason DUPE without calling upstream.
+// Requests that would be rejected anyway (invalid event details, unknown or forbidden site)
+// fail before the token is claimed. When no verdict comes back the token is released, so
+// the client can retry with the same token.
+func (g *ReplayGuard) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
+	}
+	if err := EventDetailsFromContext(ctx).validate(); err != nil {
+		return AssessmentResult{}, err
+	}
+	if _, err := scopeOf(ctx, g.next); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	key := hashToken(token)
+
+	firstUse, err := g.store.Claim(ctx, key, g.ttl)
+	if err != nil {
+		// Google still flags most duplicates, so keep serving rather than failing every request.
+		logger.Log.Error("replay store unavailable, skipping replay check", "error", err)
+		return g.next.Assess(ctx, token, action)
+	}
+
+	if !firstUse {
+		metrics.Inc(metrics.ReplayedTokens, metrics.Action(action))
+		logger.Log.Warn("token replay rejected", "action", action)
+		return AssessmentResult{Valid: false, Action: action, InvalidReason: InvalidReasonDupe}, nil
+	}
+
+	result, err := g.next.Assess(ctx, token, action)
+	if err != nil {
+		if releaseErr := g.store.Release(ctx, key); releaseErr != nil {
+			logger.Log.Error("failed to release token in replay store", "error", releaseErr)
+		}
+	}
+
+	return result, err
+}
+
+// Scope implements Scoper on behalf of the guarded assessor.
+func (g *ReplayGuard) Scope(ctx context.Context) (string, error) {
+	return scopeOf(ctx, g.next)
+}
+
+// hashToken derives the key used to track a token without keeping the token itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func TestReplayGuard_RejectsReusedToken(t *testing.T) {
+	store := NewMemoryReplayStore()
+	defer store.Stop()
+
+	upstream := &stubAssessor{result: AssessmentResult{Valid: true, Score: 0.9}}
+	guard := NewReplayGuard(upstream, store, time.Minute)
+
+	first, err := guard.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !first.Valid {
+		t.Fatal("expected first use to be valid")
+	}
+
+	second, err := guard.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Valid || second.InvalidReason != InvalidReasonDupe {
+		t.Errorf("expected replay to be rejected as DUPE, got %+v", second)
+	}
+	if upstream.calls != 1 {
+		t.Errorf("expected a single upstream call, got %d", upstream.calls)
+	}
+}
+
+func TestReplayGuard_ReleasesTokenOnUpstreamFailure(t *testing.T) {
+	store := NewMemoryReplayStore()
+	defer store.Stop()
+
+	upstream := &stubAssessor{err: apperrors.NewRecaptchaError("down", nil)}
+	guard := NewReplayGuard(upstream, store, time.Minute)
+
+	if _, err := guard.Assess(context.Background(), "token", "login"); err == nil {
+		t.Fatal("expected upstream error")
+	}
+
+	upstream.err = nil
+	upstream.result = AssessmentResult{Valid: true}
+	result, err := guard.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid {
+		t.Error("expected token to be accepted after the failed attempt")
+	}
+}
+
+func TestReplayGuard_RejectedRequestKeepsToken(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true, "action": "checkout"}, "riskAnalysis": {"score": 0.9}}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryReplayStore()
+	defer store.Stop()
+	guard := NewReplayGuard(NewRecaptchaService("api-key", "site-key", server.URL), store, time.Minute)
+
+	invalid := ContextWithEventDetails(context.Background(), EventDetails{Transaction: &TransactionData{Value: -1}})
+	if _, err := guard.Assess(invalid, "token", "checkout"); err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	fixed := ContextWithEventDetails(context.Background(), EventDetails{Transaction: &TransactionData{Value: 10}})
+	result, err := guard.Assess(fixed, "token", "checkout")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid || calls != 1 {
+		t.Errorf("expected the corrected retry to be assessed once, got %+v after %d calls", result, calls)
+	}
+}
+
+func TestReplayGuard_ReleasesTokenOnRejection(t *testing.T) {
+	store := NewMemoryReplayStore()
+	defer store.Stop()
+
+	upstream := &stubAssessor{err: apperrors.NewValidationError("rejected", nil)}
+	guard := NewReplayGuard(upstream, store, time.Minute)
+
+	if _, err := guard.Assess(context.Background(), "token", "login"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	upstream.err = nil
+	upstream.result = AssessmentResult{Valid: true}
+	result, err := guard.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid {
+		t.Error("expected token to be accepted after the rejected attempt")
+	}
+}
+
+func TestMemoryReplayStore_Expiry(t *testing.T) {
+	store := NewMemoryReplayStore()
+	defer store.Stop()
+
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	if first, _ := store.Claim(context.Background(), "key", time.Minute); !first {
+		t.Fatal("expected first claim to succeed")
+	}
+	if first, _ := store.Claim(context.Background(), "key", time.Minute); first {
+		t.Fatal("expected second claim within ttl to fail")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if first, _ := store.Claim(context.Background(), "key", time.Minute); !first {
+		t.Error("expected claim after ttl to succeed")
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+// Retry defaults used when RetryPolicy leaves a field unset.
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBaseDelay   = 100 * time.Millisecond
+	DefaultRetryMaxDelay    = 2 * time.Second
+	DefaultRetryBudget      = 10 * time.Second
+)
+
+// RetryPolicy controls how transient upstream failures are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff cap for the first retry; it doubles on every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between two attempts.
+	MaxDelay time.Duration
+	// Budget caps the time spent on all attempts, on top of the request's own deadline.
+	Budget time.Duration
+}
+
+// withDefaults fills unset fields with the package defaults.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryMaxDelay
+	}
+	if p.Budget <= 0 {
+		p.Budget = DefaultRetryBudget
+	}
+	return p
+}
+
+// delay returns the wait before the given retry (1-based) using exponential backoff with
+// full jitter. A Retry-After hint from upstream takes precedence when it is longer.
+func (p RetryPolicy) delay(retry int, retryAfter time.Duration) time.Duration {
+	ceiling := p.BaseDelay << (retry - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	wait := time.Duration(rand.Int64N(int64(ceiling) + 1))
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	return wait
+}
+
+// isRetriable classifies a single upstream call: connection failures, 429 and 5xx are
+// transient, while any other status (4xx) will not change by trying again.
+func isRetriable(resp upstreamResponse, err error) bool {
+	if err != nil {
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) && appErr.Code == apperrors.ErrCodeInternalError {
+			return false
+		}
+		// Retrying a call shed for lack of quota would only add to the queue.
+		if isShed(err) {
+			return false
+		}
+		return true
+	}
+	return resp.status == http.StatusTooManyRequests || resp.status >= 500
+}
+
+// parseRetryAfter reads a Retry-After header expressed in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fitsDeadline reports whether waiting d still leaves time before ctx's deadline.
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
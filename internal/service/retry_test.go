@@ -0,0 +1,44 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}.withDefaults()
+
+	for retry := 1; retry <= 10; retry++ {
+		for i := 0; i < 50; i++ {
+			if wait := policy.delay(retry, 0); wait < 0 || wait > time.Second {
+				t.Fatalf("retry %d: delay %s outside [0, 1s]", retry, wait)
+			}
+		}
+	}
+
+	if wait := policy.delay(1, 3*time.Second); wait != 3*time.Second {
+		t.Errorf("expected Retry-After to take precedence, got %s", wait)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"invalid", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	}
+
+	for _, tc := range testCases {
+		if got := parseRetryAfter(tc.value, now); got != tc.expected {
+			t.Errorf("parseRetryAfter(%q) = %s, expected %s", tc.value, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Scoper is implemented by assessors whose outcome depends on more than the token and action,
+// such as the site a request is routed to. Wrapping assessors pass it through, so decorators
+// that share assessments between requests only share them within the same scope.
+type Scoper interface {
+	// Scope identifies what ctx is assessed under. It fails like Assess would, e.g. for an unknown site.
+	Scope(ctx context.Context) (string, error)
+}
+
+// scopeOf returns the scope of assessor for ctx, or an empty scope when it has none.
+func scopeOf(ctx context.Context, assessor Assessor) (string, error) {
+	if scoper, ok := assessor.(Scoper); ok {
+		return scoper.Scope(ctx)
+	}
+	return "", nil
+}
+
+type partitionKey struct{}
+
+// ContextWithPartition marks ctx as belonging to partition, e.g. a tenant. Requests in different
+// partitions never share an assessment.
+func ContextWithPartition(ctx context.Context, partition string) context.Context {
+	return context.WithValue(ctx, partitionKey{}, partition)
+}
+
+func partitionFromContext(ctx context.Context) string {
+	partition, _ := ctx.Value(partitionKey{}).(string)
+	return partition
+}
+
+// assessmentKey identifies the upstream assessment a request would make: its token, action,
+// partition, the scope of next, and the event details sent along. Requests with the same key can
+// share a result. The token itself is only kept hashed.
+func assessmentKey(ctx context.Context, next Assessor, token, action string) (string, error) {
+	scope, err := scopeOf(ctx, next)
+	if err != nil {
+		return "", err
+	}
+	details, err := json.Marshal(EventDetailsFromContext(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		token, action, partitionFromContext(ctx), scope, string(details),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/logger"
+)
+
+// DefaultTokenURL is Google's OAuth2 token endpoint.
+const DefaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// Access token settings of the JWT bearer grant.
+const (
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	assertionLifetime  = time.Hour
+	// tokenRefreshMargin renews access tokens this long before they expire.
+	tokenRefreshMargin = time.Minute
+)
+
+// TokenSource supplies OAuth2 access tokens for the Enterprise API.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// ServiceAccountKey is the JSON key file of a Google Cloud service account.
+type ServiceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// LoadServiceAccountKey reads a service account JSON key file.
+func LoadServiceAccountKey(path string) (*ServiceAccountKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read service account key: %w", err)
+	}
+
+	var key ServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("parse service account key: %w", err)
+	}
+	if key.Type != "service_account" {
+		return nil, fmt.Errorf("unsupported credentials type %q", key.Type)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("service account key requires client_email and private_key")
+	}
+
+	return &key, nil
+}
+
+// ServiceAccountTokenSource exchanges RS256-signed JWT assertions for access tokens and caches
+// them until shortly before they expire. It is safe for concurrent use.
+type ServiceAccountTokenSource struct {
+	client     *http.Client
+	email      string
+	keyID      string
+	privateKey *rsa.PrivateKey
+	tokenURL   string
+	now        func() time.Time
+
+	mu         sync.Mutex
+	token      string
+	expires    time.Time
+	refreshing *tokenRefresh
+}
+
+// tokenRefresh is a token fetch shared by the callers that found the cached token stale.
+type tokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// NewServiceAccountTokenSource creates a token source for key. The token endpoint is tokenURL
+// when set, otherwise the token_uri of the key, otherwise DefaultTokenURL. A nil client uses
+// a default HTTP client.
+func NewServiceAccountTokenSource(key *ServiceAccountKey, tokenURL string, client *http.Client) (*ServiceAccountTokenSource, error) {
+	privateKey, err := parseRSAPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenURL == "" {
+		tokenURL = key.TokenURI
+	}
+	if tokenURL == "" {
+		tokenURL = DefaultTokenURL
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: DefaultOutboundTimeout}
+	}
+
+	return &ServiceAccountTokenSource{
+		client:     client,
+		email:      key.ClientEmail,
+		keyID:      key.PrivateKeyID,
+		privateKey: privateKey,
+		tokenURL:   tokenURL,
+		now:        time.Now,
+	}, nil
+}
+
+func parseRSAPrivateKey(encoded string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("service account private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("service account private key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse service account private key: %w", err)
+	}
+	return key, nil
+}
+
+// Token returns a cached access token, fetching a new one when it is about to expire.
+// Concurrent callers share a single refresh, and each stops waiting when its own ctx ends.
+func (s *ServiceAccountTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	if s.token != "" && s.now().Before(s.expires.Add(-tokenRefreshMargin)) {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+	refresh := s.refreshing
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		s.refreshing = refresh
+		go s.refresh(context.WithoutCancel(ctx), refresh)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return "", apperrors.NewRecaptchaError("failed to authenticate with Google", ctx.Err())
+	}
+}
+
+// refresh fetches a token on behalf of every waiting caller. It is detached from the caller
+// that started it, so that caller going away does not fail the others, and is bounded by
+// DefaultOutboundTimeout instead.
+func (s *ServiceAccountTokenSource) refresh(ctx context.Context, refresh *tokenRefresh) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultOutboundTimeout)
+	defer cancel()
+
+	token, lifetime, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	if err == nil {
+		s.token = token
+		s.expires = s.now().Add(lifetime)
+	}
+	s.refreshing = nil
+	s.mu.Unlock()
+
+	refresh.token, refresh.err = token, err
+	close(refresh.done)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// fetch signs an assertion and exchanges it at the token endpoint.
+func (s *ServiceAccountTokenSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	assertion, err := s.assertion()
+	if err != nil {
+		logger.Log.Error("failed to sign service account assertion", "error", err)
+		return "", 0, apperrors.NewInternalError("failed to authenticate with Google", err)
+	}
+
+	form := url.Values{"grant_type": {jwtBearerGrantType}, "assertion": {assertion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, apperrors.NewInternalError("failed to create token request", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		logger.Log.Error("request to OAuth2 token endpoint failed", "error", err)
+		return "", 0, apperrors.NewRecaptchaError("failed to authenticate with Google", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, apperrors.NewRecaptchaError("failed to authenticate with Google", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.Log.Error("OAuth2 token endpoint returned error", "status", resp.StatusCode, "body", trimErrorBody(body))
+		return "", 0, apperrors.NewRecaptchaError(
+			"failed to authenticate with Google",
+			fmt.Errorf("token endpoint status %d: %s", resp.StatusCode, trimErrorBody(body)),
+		)
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", 0, apperrors.NewRecaptchaError("invalid token endpoint response", err)
+	}
+
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = assertionLifetime
+	}
+	return token.AccessToken, lifetime, nil
+}
+
+// assertion builds the RS256-signed JWT of the bearer grant.
+func (s *ServiceAccountTokenSource) assertion() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.keyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   s.email,
+		"scope": cloudPlatformScope,
+		"aud":   s.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(assertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
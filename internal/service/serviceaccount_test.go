@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeServiceAccountKey creates a key file for a fresh RSA key and returns its path and public key.
+func writeServiceAccountKey(t *testing.T, tokenURI string) (string, *rsa.PublicKey) {
+	t.Helper()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := json.Marshal(ServiceAccountKey{
+		Type:         "service_account",
+		ProjectID:    "project",
+		PrivateKeyID: "key-id",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ClientEmail:  "recaptcha@project.iam.gserviceaccount.com",
+		TokenURI:     tokenURI,
+	})
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path, &privateKey.PublicKey
+}
+
+func TestServiceAccountTokenSource(t *testing.T) {
+	var fetches atomic.Int32
+	var publicKey *rsa.PublicKey
+	var tokenURL string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetch := fetches.Add(1)
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("invalid form: %v", err)
+		}
+		if r.PostForm.Get("grant_type") != jwtBearerGrantType {
+			t.Errorf("unexpected grant type %q", r.PostForm.Get("grant_type"))
+		}
+
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("malformed assertion")
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("invalid assertion signature: %v", err)
+		}
+
+		var claims map[string]any
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		_ = json.Unmarshal(payload, &claims)
+		if claims["iss"] != "recaptcha@project.iam.gserviceaccount.com" || claims["aud"] != tokenURL || claims["scope"] != cloudPlatformScope {
+			t.Errorf("unexpected claims %v", claims)
+		}
+
+		fmt.Fprintf(w, `{"access_token": "access-%d", "expires_in": 3600, "token_type": "Bearer"}`, fetch)
+	}))
+	defer server.Close()
+	tokenURL = server.URL + "/token"
+
+	path, pub := writeServiceAccountKey(t, "https://oauth2.invalid/token")
+	publicKey = pub
+
+	key, err := LoadServiceAccountKey(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tokens, err := NewServiceAccountTokenSource(key, tokenURL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	tokens.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		token, err := tokens.Token(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token != "access-1" {
+			t.Errorf("expected cached token, got %q", token)
+		}
+	}
+
+	// Within the refresh margin of the expiry a new token is fetched.
+	now = now.Add(time.Hour - tokenRefreshMargin/2)
+	token, err := tokens.Token(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "access-2" || fetches.Load() != 2 {
+		t.Errorf("expected refreshed token after %d fetches, got %q", fetches.Load(), token)
+	}
+}
+
+func TestServiceAccountTokenSource_EndpointError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+	}))
+	defer server.Close()
+
+	path, _ := writeServiceAccountKey(t, server.URL)
+	key, err := LoadServiceAccountKey(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tokens, err := NewServiceAccountTokenSource(key, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := tokens.Token(context.Background()); !IsUpstreamFailure(err) {
+		t.Errorf("expected upstream failure, got %v", err)
+	}
+}
+
+func TestServiceAccountTokenSource_SlowEndpoint(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"access_token": "access", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	path, _ := writeServiceAccountKey(t, server.URL)
+	key, err := LoadServiceAccountKey(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tokens, err := NewServiceAccountTokenSource(key, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A caller whose deadline passes stops waiting without holding up the refresh.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tokens.Token(ctx); !IsUpstreamFailure(err) {
+		t.Errorf("expected upstream failure, got %v", err)
+	}
+
+	results := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			token, err := tokens.Token(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- token
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 3; i++ {
+		if token := <-results; token != "access" {
+			t.Errorf("expected the shared token, got %q", token)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("expected a single fetch, got %d", fetches.Load())
+	}
+}
+
+func TestLoadServiceAccountKey_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, []byte(`{"type": "authorized_user"}`), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := LoadServiceAccountKey(path); err == nil {
+		t.Error("expected an error for non service account credentials")
+	}
+}
+
+type staticTokenSource string
+
+func (s staticTokenSource) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+func TestRecaptchaService_Assess_TokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("X-goog-api-key") != "" {
+			t.Error("expected no API key header")
+		}
+		_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true, "action": "login"}, "riskAnalysis": {"score": 0.9}}`))
+	}))
+	defer server.Close()
+
+	svc := NewRecaptchaService("", "site-key", server.URL, WithTokenSource(staticTokenSource("access-token")))
+	if _, err := svc.Assess(context.Background(), "token", "login"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
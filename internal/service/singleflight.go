@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/logger"
+	"api-recaptcha/internal/metrics"
+)
+
+// inflightAssessment is an upstream assessment shared by the callers that asked for it.
+type inflightAssessment struct {
+	done   chan struct{}
+	result AssessmentResult
+	err    error
+	// canceled is set when the call failed because its caller went away, which says nothing
+	// about the token: the callers still waiting run their own call instead.
+	canceled bool
+}
+
+// Singleflight coalesces concurrent identical assessments, so a double-submitted form makes a
+// single upstream call and every caller gets the same result. Assessments are identical when
+// they share token, action, partition, scope and event details (see assessmentKey).
+type Singleflight struct {
+	next Assessor
+
+	mu       sync.Mutex
+	inflight map[string]*inflightAssessment
+}
+
+// NewSingleflight wraps next with deduplication of concurrent identical calls.
+func NewSingleflight(next Assessor) *Singleflight {
+	return &Singleflight{next: next, inflight: make(map[string]*inflightAssessment)}
+}
+
+// Assess joins the identical call in flight, or starts it. Waiting callers give up when their
+// own context ends.
+func (s *Singleflight) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	key, err := assessmentKey(ctx, s.next, token, action)
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+
+	for {
+		s.mu.Lock()
+		call, joined := s.inflight[key]
+		if !joined {
+			call = &inflightAssessment{done: make(chan struct{})}
+			s.inflight[key] = call
+		}
+		s.mu.Unlock()
+
+		if !joined {
+			return s.lead(ctx, key, call, token, action)
+		}
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return AssessmentResult{}, apperrors.NewRequestCanceledError(ctx.Err())
+		}
+		if call.canceled {
+			continue
+		}
+
+		metrics.Inc(metrics.CoalescedAssessments, metrics.Action(action))
+		logger.Log.Debug("assessment served by a concurrent identical call", "action", action)
+		return call.result, call.err
+	}
+}
+
+// Scope implements Scoper on behalf of the wrapped assessor.
+func (s *Singleflight) Scope(ctx context.Context) (string, error) {
+	return scopeOf(ctx, s.next)
+}
+
+// lead performs the upstream call and hands its outcome to the callers waiting on it.
+// If the call panics, the waiting callers run their own call.
+func (s *Singleflight) lead(ctx context.Context, key string, call *inflightAssessment, token, action string) (AssessmentResult, error) {
+	call.canceled = true
+	defer func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.result, call.err = s.next.Assess(ctx, token, action)
+	call.canceled = call.err != nil && ctx.Err() != nil
+
+	return call.result, call.err
+}
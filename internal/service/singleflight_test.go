@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+// gatedAssessor blocks every call until release is closed, or until the caller's context ends.
+type gatedAssessor struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGatedAssessor() *gatedAssessor {
+	return &gatedAssessor{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (g *gatedAssessor) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	n := g.calls.Add(1)
+	g.started <- struct{}{}
+	select {
+	case <-g.release:
+		return AssessmentResult{Valid: true, Score: float64(n) / 10, Action: action}, nil
+	case <-ctx.Done():
+		return AssessmentResult{}, ctx.Err()
+	}
+}
+
+// siteScopedAssessor scopes requests by the site they name, like a SiteRegistry.
+type siteScopedAssessor struct {
+	Assessor
+}
+
+func (a siteScopedAssessor) Scope(ctx context.Context) (string, error) {
+	return siteSelectorFromContext(ctx).Name, nil
+}
+
+func TestSingleflight_CoalescesConcurrentCalls(t *testing.T) {
+	upstream := newGatedAssessor()
+	flight := NewSingleflight(NewReplayGuard(upstream, NewMemoryReplayStore(), time.Minute))
+
+	const callers = 5
+	results := make([]AssessmentResult, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := flight.Assess(context.Background(), "token", "login")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = result
+		}(i)
+		if i == 0 {
+			<-upstream.started
+		}
+	}
+
+	// Let the other callers join the call in flight before it completes.
+	time.Sleep(20 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if upstream.calls.Load() != 1 {
+		t.Errorf("expected a single upstream call, got %d", upstream.calls.Load())
+	}
+	for i, result := range results {
+		if !result.Valid || result.InvalidReason == InvalidReasonDupe || result.Score != results[0].Score {
+			t.Errorf("caller %d: expected the shared result, got %+v", i, result)
+		}
+	}
+}
+
+func TestSingleflight_DifferentActionsAreNotCoalesced(t *testing.T) {
+	upstream := newGatedAssessor()
+	close(upstream.release)
+	flight := NewSingleflight(upstream)
+
+	for _, action := range []string{"login", "signup"} {
+		if _, err := flight.Assess(context.Background(), "token", action); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if upstream.calls.Load() != 2 {
+		t.Errorf("expected one call per action, got %d", upstream.calls.Load())
+	}
+}
+
+func TestSingleflight_CanceledLeader(t *testing.T) {
+	upstream := newGatedAssessor()
+	flight := NewSingleflight(upstream)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := flight.Assess(leaderCtx, "token", "login")
+		leaderDone <- err
+	}()
+	<-upstream.started
+
+	waiterDone := make(chan AssessmentResult, 1)
+	go func() {
+		result, err := flight.Assess(context.Background(), "token", "login")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		waiterDone <- result
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The leader goes away: the waiter must run its own call rather than inherit the cancellation.
+	cancel()
+	if err := <-leaderDone; err == nil {
+		t.Error("expected the canceled leader to fail")
+	}
+	<-upstream.started
+	close(upstream.release)
+
+	if result := <-waiterDone; !result.Valid {
+		t.Errorf("expected the waiter to get its own result, got %+v", result)
+	}
+	if upstream.calls.Load() != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", upstream.calls.Load())
+	}
+}
+
+func TestSingleflight_DifferentRequestsAreNotCoalesced(t *testing.T) {
+	base := context.Background()
+
+	testCases := []struct {
+		name   string
+		first  context.Context
+		second context.Context
+	}{
+		{
+			name:   "different sites",
+			first:  ContextWithSite(base, SiteSelector{Name: "shop"}),
+			second: ContextWithSite(base, SiteSelector{Name: "blog"}),
+		},
+		{
+			name:   "different tenants",
+			first:  ContextWithPartition(base, "tenant:a"),
+			second: ContextWithPartition(base, "tenant:b"),
+		},
+		{
+			name:   "different transactions",
+			first:  ContextWithEventDetails(base, EventDetails{Transaction: &TransactionData{TransactionID: "tx-1", Value: 10}}),
+			second: ContextWithEventDetails(base, EventDetails{Transaction: &TransactionData{TransactionID: "tx-2", Value: 500}}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstream := newGatedAssessor()
+			flight := NewSingleflight(siteScopedAssessor{upstream})
+
+			var wg sync.WaitGroup
+			for _, ctx := range []context.Context{tc.first, tc.second} {
+				wg.Add(1)
+				go func(ctx context.Context) {
+					defer wg.Done()
+					if _, err := flight.Assess(ctx, "token", "checkout"); err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+				}(ctx)
+			}
+
+			// Both calls reach upstream while neither has completed.
+			<-upstream.started
+			<-upstream.started
+			close(upstream.release)
+			wg.Wait()
+
+			if upstream.calls.Load() != 2 {
+				t.Errorf("expected 2 upstream calls, got %d", upstream.calls.Load())
+			}
+		})
+	}
+}
+
+func TestSingleflight_CanceledWaiter(t *testing.T) {
+	upstream := newGatedAssessor()
+	defer close(upstream.release)
+	flight := NewSingleflight(upstream)
+
+	go func() { _, _ = flight.Assess(context.Background(), "token", "login") }()
+	<-upstream.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := flight.Assess(ctx, "token", "login")
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeRequestCanceled {
+		t.Fatalf("expected %s, got %v", apperrors.ErrCodeRequestCanceled, err)
+	}
+	if IsUpstreamFailure(err) {
+		t.Error("expected a canceled waiter not to count as an upstream failure")
+	}
+}
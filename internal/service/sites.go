@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+// SiteConfig describes one reCAPTCHA Enterprise site: its keys, the Google Cloud project
+// assessments are created in, and how requests are matched to it.
+type SiteConfig struct {
+	Name           string `json:"name"`
+	SiteKey        string `json:"siteKey"`
+	ExpressSiteKey string `json:"expressSiteKey,omitempty"`
+	ProjectID      string `json:"projectId"`
+	// GoogleAPIKey falls back to GOOGLE_RECAPTCHA_API_KEY when empty.
+	GoogleAPIKey string `json:"googleApiKey,omitempty"`
+	// ServiceAccountFile authenticates the site with a service account instead of an API key.
+	ServiceAccountFile string `json:"serviceAccountFile,omitempty"`
+	// Origins select the site for browser requests carrying one of these Origin headers.
+	Origins []string `json:"origins,omitempty"`
+	// AppAPIKeys select the site for requests authenticated with one of these keys.
+	AppAPIKeys []string `json:"appApiKeys,omitempty"`
+
+	TokenMaxAgeSeconds     int      `json:"tokenMaxAgeSeconds,omitempty"`
+	AllowedHostnames       []string `json:"allowedHostnames,omitempty"`
+	AllowedAndroidPackages []string `json:"allowedAndroidPackages,omitempty"`
+	AllowedIOSBundles      []string `json:"allowedIosBundles,omitempty"`
+}
+
+// Constraints returns the token constraints configured for the site.
+func (c SiteConfig) Constraints() TokenConstraints {
+	return TokenConstraints{
+		MaxAge:              time.Duration(c.TokenMaxAgeSeconds) * time.Second,
+		Hostnames:           c.AllowedHostnames,
+		AndroidPackageNames: c.AllowedAndroidPackages,
+		IOSBundleIDs:        c.AllowedIOSBundles,
+	}
+}
+
+// SitesConfig is the file representation of the site registry.
+type SitesConfig struct {
+	Default string       `json:"default,omitempty"`
+	Sites   []SiteConfig `json:"sites"`
+}
+
+// LoadSitesFile reads a JSON site configuration from disk. ${VAR} references are replaced
+// with environment variables so secrets can stay out of the file.
+func LoadSitesFile(path string) (SitesConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SitesConfig{}, fmt.Errorf("read sites file: %w", err)
+	}
+
+	var cfg SitesConfig
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return SitesConfig{}, fmt.Errorf("parse sites file: %w", err)
+	}
+	if len(cfg.Sites) == 0 {
+		return SitesConfig{}, fmt.Errorf("sites file defines no site")
+	}
+	if cfg.Default == "" {
+		cfg.Default = cfg.Sites[0].Name
+	}
+
+	return cfg, nil
+}
+
+// SiteSelector carries what a request says about the site it belongs to.
+type SiteSelector struct {
+	// Name is the site named explicitly in the request.
+	Name string
+	// SiteKey is the site key the token was issued for, when the request names it.
+	SiteKey string
+	Origin  string
+	APIKey  string
+}
+
+type siteSelectorKey struct{}
+
+// ContextWithSite attaches the site selector of a request to ctx.
+func ContextWithSite(ctx context.Context, selector SiteSelector) context.Context {
+	return context.WithValue(ctx, siteSelectorKey{}, selector)
+}
+
+func siteSelectorFromContext(ctx context.Context) SiteSelector {
+	selector, _ := ctx.Value(siteSelectorKey{}).(SiteSelector)
+	return selector
+}
+
+// SiteRegistry routes Enterprise calls to the RecaptchaService of the request's site.
+// An app API key assigned to a site pins the request to that site. Otherwise the site is the one
+// named in the request, otherwise the one owning the site key, otherwise the one matching the
+// Origin header, otherwise the default site.
+type SiteRegistry struct {
+	sites       map[string]*RecaptchaService
+	bySiteKey   map[string]string
+	byOrigin    map[string]string
+	byAPIKey    map[string]string
+	defaultSite string
+}
+
+// NewSiteRegistry creates an empty registry that falls back to defaultSite.
+func NewSiteRegistry(defaultSite string) *SiteRegistry {
+	return &SiteRegistry{
+		sites:       make(map[string]*RecaptchaService),
+		bySiteKey:   make(map[string]string),
+		byOrigin:    make(map[string]string),
+		byAPIKey:    make(map[string]string),
+		defaultSite: defaultSite,
+	}
+}
+
+// Register adds a site served by svc. Origins and app API keys must not belong to another site.
+func (r *SiteRegistry) Register(name string, svc *RecaptchaService, origins, appAPIKeys []string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("site without name")
+	}
+	if _, exists := r.sites[name]; exists {
+		return fmt.Errorf("duplicate site %q", name)
+	}
+
+	for _, siteKey := range []string{svc.siteKey, svc.expressSiteKey} {
+		if siteKey == "" {
+			continue
+		}
+		if owner, exists := r.bySiteKey[siteKey]; exists && owner != name {
+			return fmt.Errorf("site %q: site key already belongs to site %q", name, owner)
+		}
+		r.bySiteKey[siteKey] = name
+	}
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if owner, exists := r.byOrigin[origin]; exists {
+			return fmt.Errorf("site %q: origin %q already belongs to site %q", name, origin, owner)
+		}
+		r.byOrigin[origin] = name
+	}
+	for _, key := range appAPIKeys {
+		if owner, exists := r.byAPIKey[key]; exists {
+			return fmt.Errorf("site %q: app API key already belongs to site %q", name, owner)
+		}
+		r.byAPIKey[key] = name
+	}
+
+	r.sites[name] = svc
+	return nil
+}
+
+// SiteKeys returns the site keys of every registered site.
+func (r *SiteRegistry) SiteKeys() []string {
+	keys := make([]string, 0, len(r.bySiteKey))
+	for key := range r.bySiteKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AppAPIKeys returns the app API keys assigned to sites.
+func (r *SiteRegistry) AppAPIKeys() []string {
+	keys := make([]string, 0, len(r.byAPIKey))
+	for key := range r.byAPIKey {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Sites returns the sorted names of the registered sites.
+func (r *SiteRegistry) Sites() []string {
+	names := make([]string, 0, len(r.sites))
+	for name := range r.sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the site name and service for the selector. The app API key is the only
+// authenticated selector, so a name or site key pointing away from its site is rejected.
+func (r *SiteRegistry) Resolve(selector SiteSelector) (string, *RecaptchaService, error) {
+	name := strings.TrimSpace(selector.Name)
+	if name == "" && selector.SiteKey != "" {
+		var ok bool
+		if name, ok = r.bySiteKey[strings.TrimSpace(selector.SiteKey)]; !ok {
+			return "", nil, apperrors.NewValidationError("unknown site key", nil)
+		}
+	}
+	if bound, ok := r.byAPIKey[selector.APIKey]; ok && selector.APIKey != "" {
+		if name != "" && name != bound {
+			return "", nil, apperrors.NewForbiddenError("site not allowed for this API key",
+				fmt.Errorf("site %q requested with an API key of site %q", name, bound))
+		}
+		name = bound
+	}
+	if name == "" {
+		name = r.byOrigin[strings.TrimRight(selector.Origin, "/")]
+	}
+	if name == "" {
+		name = r.defaultSite
+	}
+
+	svc, ok := r.sites[name]
+	if !ok {
+		return "", nil, apperrors.NewValidationError("unknown site", fmt.Errorf("site %q", name))
+	}
+	return name, svc, nil
+}
+
+// Assess implements Assessor on the site selected by ctx.
+func (r *SiteRegistry) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	name, svc, err := r.Resolve(siteSelectorFromContext(ctx))
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+	result, err := svc.Assess(ctx, token, action)
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+	result.Site = name
+	return result, nil
+}
+
+// Scope implements Scoper: requests are assessed under the site selected by ctx.
+func (r *SiteRegistry) Scope(ctx context.Context) (string, error) {
+	name, _, err := r.Resolve(siteSelectorFromContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return "site:" + name, nil
+}
+
+// AssessExpress implements ExpressAssessor on the site selected by ctx.
+func (r *SiteRegistry) AssessExpress(ctx context.Context, action string) (AssessmentResult, error) {
+	name, svc, err := r.Resolve(siteSelectorFromContext(ctx))
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+	result, err := svc.AssessExpress(ctx, action)
+	if err != nil {
+		return AssessmentResult{}, err
+	}
+	result.Site = name
+	return result, nil
+}
+
+// Annotate implements Annotator on the site selected by ctx.
+func (r *SiteRegistry) Annotate(ctx context.Context, assessmentID string, annotation Annotation, reasons []AnnotationReason) error {
+	_, svc, err := r.Resolve(siteSelectorFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	return svc.Annotate(ctx, assessmentID, annotation, reasons)
+}
+
+// CheckPasswordLeak implements PasswordLeakChecker on the site selected by ctx.
+func (r *SiteRegistry) CheckPasswordLeak(ctx context.Context, username, password string) (bool, error) {
+	_, svc, err := r.Resolve(siteSelectorFromContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	return svc.CheckPasswordLeak(ctx, username, password)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func newTestSiteRegistry(t *testing.T) *SiteRegistry {
+	t.Helper()
+
+	registry := NewSiteRegistry("shop")
+	if err := registry.Register("shop", NewRecaptchaService("key", "shop-key", "http://shop", WithExpressSiteKey("shop-express")),
+		[]string{"https://shop.example.com/"}, []string{"shop-app"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.Register("blog", NewRecaptchaService("key", "blog-key", "http://blog"),
+		[]string{"https://blog.example.com"}, []string{"blog-app"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return registry
+}
+
+func TestSiteRegistry_Resolve(t *testing.T) {
+	registry := newTestSiteRegistry(t)
+
+	testCases := []struct {
+		name       string
+		selector   SiteSelector
+		expected   string
+		wantStatus int
+	}{
+		{"default", SiteSelector{}, "shop", 0},
+		{"explicit name", SiteSelector{Name: "blog", Origin: "https://shop.example.com"}, "blog", 0},
+		{"site key", SiteSelector{SiteKey: "blog-key"}, "blog", 0},
+		{"express site key", SiteSelector{SiteKey: "shop-express"}, "shop", 0},
+		{"origin", SiteSelector{Origin: "https://blog.example.com/"}, "blog", 0},
+		{"app API key", SiteSelector{Origin: "https://other.example.com", APIKey: "blog-app"}, "blog", 0},
+		{"app API key over origin", SiteSelector{Origin: "https://blog.example.com", APIKey: "shop-app"}, "shop", 0},
+		{"app API key with its own site", SiteSelector{Name: "shop", SiteKey: "shop-key", APIKey: "shop-app"}, "shop", 0},
+		{"unbound API key", SiteSelector{Name: "blog", APIKey: "global-key"}, "blog", 0},
+		{"name of another site", SiteSelector{Name: "blog", APIKey: "shop-app"}, "", http.StatusForbidden},
+		{"site key of another site", SiteSelector{SiteKey: "blog-key", APIKey: "shop-app"}, "", http.StatusForbidden},
+		{"unknown site", SiteSelector{Name: "missing"}, "", http.StatusBadRequest},
+		{"unknown site key", SiteSelector{SiteKey: "missing-key"}, "", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, _, err := registry.Resolve(tc.selector)
+			if tc.wantStatus != 0 {
+				var appErr *apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.HTTPStatus != tc.wantStatus {
+					t.Fatalf("expected error with status %d, got %v", tc.wantStatus, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tc.expected {
+				t.Errorf("expected site %q, got %q", tc.expected, name)
+			}
+		})
+	}
+}
+
+func TestSiteRegistry_RegisterConflicts(t *testing.T) {
+	registry := newTestSiteRegistry(t)
+
+	if err := registry.Register("shop", NewRecaptchaService("key", "new-key", "http://x"), nil, nil); err == nil {
+		t.Error("expected an error for a duplicate site")
+	}
+	if err := registry.Register("news", NewRecaptchaService("key", "blog-key", "http://x"), nil, nil); err == nil {
+		t.Error("expected an error for a site key owned by another site")
+	}
+	if err := registry.Register("news", NewRecaptchaService("key", "news-key", "http://x"), []string{"https://blog.example.com"}, nil); err == nil {
+		t.Error("expected an error for an origin owned by another site")
+	}
+	if err := registry.Register("news", NewRecaptchaService("key", "news-key", "http://x"), nil, []string{"shop-app"}); err == nil {
+		t.Error("expected an error for an app API key owned by another site")
+	}
+}
+
+func TestSiteRegistry_Assess(t *testing.T) {
+	newServer := func(apiKey, siteKey string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body assessmentRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("invalid request body: %v", err)
+			}
+			if r.Header.Get("X-goog-api-key") != apiKey || body.Event.SiteKey != siteKey {
+				t.Errorf("expected %s/%s, got %s/%s", apiKey, siteKey, r.Header.Get("X-goog-api-key"), body.Event.SiteKey)
+			}
+			_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true, "action": "login"}, "riskAnalysis": {"score": 0.8}}`))
+		}))
+	}
+	shop := newServer("shop-google", "shop-key")
+	defer shop.Close()
+	blog := newServer("blog-google", "blog-key")
+	defer blog.Close()
+
+	registry := NewSiteRegistry("shop")
+	_ = registry.Register("shop", NewRecaptchaService("shop-google", "shop-key", shop.URL), nil, nil)
+	_ = registry.Register("blog", NewRecaptchaService("blog-google", "blog-key", blog.URL), []string{"https://blog.example.com"}, nil)
+
+	ctx := ContextWithSite(context.Background(), SiteSelector{Origin: "https://blog.example.com"})
+	result, err := registry.Assess(ctx, "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Site != "blog" || result.Score != 0.8 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	result, err = registry.Assess(context.Background(), "token", "login")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Site != "shop" {
+		t.Errorf("expected default site, got %q", result.Site)
+	}
+}
+
+func TestLoadSitesFile(t *testing.T) {
+	t.Setenv("SHOP_GOOGLE_API_KEY", "secret")
+
+	path := filepath.Join(t.TempDir(), "sites.json")
+	data := `{"sites": [
+		{"name": "shop", "siteKey": "shop-key", "projectId": "shop-project", "googleApiKey": "${SHOP_GOOGLE_API_KEY}", "tokenMaxAgeSeconds": 120},
+		{"name": "blog", "siteKey": "blog-key", "projectId": "blog-project"}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := LoadSitesFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Default != "shop" || len(cfg.Sites) != 2 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Sites[0].GoogleAPIKey != "secret" {
+		t.Errorf("expected expanded API key, got %q", cfg.Sites[0].GoogleAPIKey)
+	}
+	if cfg.Sites[0].Constraints().MaxAge.Seconds() != 120 {
+		t.Errorf("unexpected constraints: %+v", cfg.Sites[0].Constraints())
+	}
+
+	if err := os.WriteFile(path, []byte(`{"sites": []}`), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := LoadSitesFile(path); err == nil {
+		t.Error("expected an error for a file without sites")
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	apperrors "api-recaptcha/internal/errors"
+	"api-recaptcha/internal/logger"
+)
+
+// DefaultSiteVerifyEndpoint is the classic (non-Enterprise) reCAPTCHA verification endpoint.
+const DefaultSiteVerifyEndpoint = "https://www.google.com/recaptcha/api/siteverify"
+
+// siteVerifyResponse is the response shape shared by the siteverify-style APIs.
+type siteVerifyResponse struct {
+	Success     bool     `json:"success"`
+	Score       *float64 `json:"score"`
+	Action      string   `json:"action"`
+	ChallengeTS string   `json:"challenge_ts"`
+	Hostname    string   `json:"hostname"`
+	ErrorCodes  []string `json:"error-codes"`
+}
+
+// VerifierOption customizes the siteverify-style providers: SiteVerifyService, HCaptchaService
+// and TurnstileService.
+type VerifierOption func(*verifierConfig)
+
+type verifierConfig struct {
+	client *http.Client
+}
+
+// WithVerifierHTTPClient replaces the default HTTP client, for example with one from NewHTTPClient.
+func WithVerifierHTTPClient(client *http.Client) VerifierOption {
+	return func(c *verifierConfig) {
+		c.client = client
+	}
+}
+
+// newVerifierConfig applies opts over the defaults.
+func newVerifierConfig(opts []VerifierOption) verifierConfig {
+	cfg := verifierConfig{client: &http.Client{Timeout: DefaultOutboundTimeout}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// SiteVerifyService verifies reCAPTCHA v2/v3 tokens issued for classic (non-Enterprise) keys.
+type SiteVerifyService struct {
+	client   *http.Client
+	secret   string
+	endpoint string
+}
+
+// NewSiteVerifyService builds a SiteVerifyService. An empty endpoint uses DefaultSiteVerifyEndpoint.
+func NewSiteVerifyService(secret, endpoint string, opts ...VerifierOption) *SiteVerifyService {
+	if endpoint == "" {
+		endpoint = DefaultSiteVerifyEndpoint
+	}
+	return &SiteVerifyService{
+		client:   newVerifierConfig(opts).client,
+		secret:   secret,
+		endpoint: endpoint,
+	}
+}
+
+// Assess validates the token against the siteverify endpoint and normalizes the outcome.
+func (s *SiteVerifyService) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	form := url.Values{}
+	form.Set("secret", s.secret)
+	form.Set("response", token)
+
+	var verification siteVerifyResponse
+	if err := postSiteVerify(ctx, s.client, s.endpoint, form, &verification); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	if err := configurationError(verification.ErrorCodes, "invalid-input-secret", "missing-input-secret", "bad-request"); err != nil {
+		logger.Log.Error("siteverify rejected the request", "error_codes", verification.ErrorCodes)
+		return AssessmentResult{}, err
+	}
+
+	result := verification.toResult()
+	result.Provider = ProviderSiteVerify
+	if !result.Valid {
+		result.InvalidReason = siteVerifyInvalidReason(verification.ErrorCodes)
+	}
+
+	return result, nil
+}
+
+// postSiteVerify sends a form-encoded verification request and decodes the JSON response into out.
+func postSiteVerify(ctx context.Context, client *http.Client, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		logger.Log.Error("failed to create siteverify request", "error", err)
+		return apperrors.NewInternalError("failed to create request", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Log.Error("request to siteverify failed", "endpoint", endpoint, "error", err)
+		return apperrors.NewRecaptchaError("failed to connect to verification service", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Log.Error("failed to read siteverify response", "error", err)
+		return apperrors.NewRecaptchaError("failed to read verification response", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		trimmed := trimErrorBody(respBody)
+		logger.Log.Error("siteverify returned error",
+			"endpoint", endpoint,
+			"status", resp.StatusCode,
+			"body", trimmed,
+		)
+		return apperrors.NewRecaptchaError(
+			"verification failed",
+			fmt.Errorf("status %d: %s", resp.StatusCode, trimmed),
+		)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		logger.Log.Error("failed to decode siteverify response", "error", err, "body", trimErrorBody(respBody))
+		return apperrors.NewInternalError("failed to parse verification response", err)
+	}
+
+	return nil
+}
+
+// toResult maps the common siteverify fields into an AssessmentResult.
+// Checkbox and invisible challenges carry no score; a solved one is reported as 1.0
+// so score policies treat it like a confident human verdict.
+func (r siteVerifyResponse) toResult() AssessmentResult {
+	result := AssessmentResult{
+		Valid:    r.Success,
+		Action:   r.Action,
+		Hostname: r.Hostname,
+	}
+	switch {
+	case r.Score != nil:
+		result.Score = *r.Score
+	case r.Success:
+		result.Score = 1
+	}
+	if ts, err := time.Parse(time.RFC3339, r.ChallengeTS); err == nil {
+		result.CreateTime = ts
+	}
+	return result
+}
+
+// configurationError returns an upstream error when any of the given codes signals
+// a problem with our own request (bad secret, malformed call) rather than with the token.
+func configurationError(codes []string, fatal ...string) error {
+	for _, code := range codes {
+		for _, f := range fatal {
+			if code == f {
+				return apperrors.NewRecaptchaError(
+					"verification failed",
+					fmt.Errorf("error codes: %s", strings.Join(codes, ",")),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// siteVerifyInvalidReason translates classic reCAPTCHA error codes into Enterprise invalid reasons.
+// The API reports expired and duplicated tokens with the same code, so both surface as EXPIRED.
+func siteVerifyInvalidReason(codes []string) InvalidReason {
+	for _, code := range codes {
+		switch code {
+		case "missing-input-response":
+			return InvalidReasonMissing
+		case "invalid-input-response":
+			return InvalidReasonMalformed
+		case "timeout-or-duplicate":
+			return InvalidReasonExpired
+		}
+	}
+	return InvalidReasonUnknown
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func TestSiteVerifyService_Assess(t *testing.T) {
+	testCases := []struct {
+		name          string
+		response      string
+		valid         bool
+		score         float64
+		invalidReason InvalidReason
+	}{
+		{
+			name:     "valid v3 token",
+			response: `{"success":true,"score":0.8,"action":"login","hostname":"example.com","challenge_ts":"2026-01-15T10:00:00Z"}`,
+			valid:    true,
+			score:    0.8,
+		},
+		{
+			name:          "expired token",
+			response:      `{"success":false,"error-codes":["timeout-or-duplicate"]}`,
+			invalidReason: "EXPIRED",
+		},
+		{
+			name:          "malformed token",
+			response:      `{"success":false,"error-codes":["invalid-input-response"]}`,
+			invalidReason: "MALFORMED",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("failed to parse form: %v", err)
+				}
+				if r.PostForm.Get("secret") != "secret" || r.PostForm.Get("response") != "token" {
+					t.Errorf("unexpected form values: %v", r.PostForm)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			svc := NewSiteVerifyService("secret", server.URL)
+			result, err := svc.Assess(context.Background(), "token", "login")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Valid != tc.valid {
+				t.Errorf("expected valid %v, got %v", tc.valid, result.Valid)
+			}
+			if result.Score != tc.score {
+				t.Errorf("expected score %f, got %f", tc.score, result.Score)
+			}
+			if result.InvalidReason != tc.invalidReason {
+				t.Errorf("expected invalid reason %q, got %q", tc.invalidReason, result.InvalidReason)
+			}
+		})
+	}
+}
+
+func TestSiteVerifyService_Assess_InvalidSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-secret"]}`))
+	}))
+	defer server.Close()
+
+	svc := NewSiteVerifyService("wrong", server.URL)
+	_, err := svc.Assess(context.Background(), "token", "")
+
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeRecaptchaFailed {
+		t.Fatalf("expected %s error, got %v", apperrors.ErrCodeRecaptchaFailed, err)
+	}
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestVerifierHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client := &http.Client{Transport: transport}
+	verifiers := map[string]Assessor{
+		ProviderSiteVerify: NewSiteVerifyService("secret", server.URL, WithVerifierHTTPClient(client)),
+		ProviderHCaptcha:   NewHCaptchaService("secret", "", server.URL, WithVerifierHTTPClient(client)),
+		ProviderTurnstile:  NewTurnstileService("secret", server.URL, WithVerifierHTTPClient(client)),
+	}
+
+	for name, verifier := range verifiers {
+		before := transport.requests
+		if _, err := verifier.Assess(context.Background(), "token", ""); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if transport.requests != before+1 {
+			t.Errorf("%s: expected the request to go through the configured client", name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"strings"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+const (
+	maxTransactionItems    = 100
+	maxTransactionFieldLen = 256
+)
+
+// TransactionData describes a payment, as in the reCAPTCHA Enterprise TransactionData message.
+// It is forwarded to Enterprise to obtain a fraud prevention assessment.
+type TransactionData struct {
+	TransactionID   string              `json:"transactionId,omitempty"`
+	PaymentMethod   string              `json:"paymentMethod,omitempty"`
+	CardBin         string              `json:"cardBin,omitempty"`
+	CardLastFour    string              `json:"cardLastFour,omitempty"`
+	CurrencyCode    string              `json:"currencyCode,omitempty"`
+	Value           float64             `json:"value,omitempty"`
+	ShippingValue   float64             `json:"shippingValue,omitempty"`
+	ShippingAddress *TransactionAddress `json:"shippingAddress,omitempty"`
+	BillingAddress  *TransactionAddress `json:"billingAddress,omitempty"`
+	Items           []TransactionItem   `json:"items,omitempty"`
+}
+
+// TransactionAddress is a shipping or billing address.
+type TransactionAddress struct {
+	Recipient          string   `json:"recipient,omitempty"`
+	Address            []string `json:"address,omitempty"`
+	Locality           string   `json:"locality,omitempty"`
+	AdministrativeArea string   `json:"administrativeArea,omitempty"`
+	RegionCode         string   `json:"regionCode,omitempty"`
+	PostalCode         string   `json:"postalCode,omitempty"`
+}
+
+// TransactionItem is a line of the purchase.
+type TransactionItem struct {
+	Name              string  `json:"name,omitempty"`
+	Value             float64 `json:"value,omitempty"`
+	Quantity          int64   `json:"quantity,omitempty"`
+	MerchantAccountID string  `json:"merchantAccountId,omitempty"`
+}
+
+// FraudPreventionAssessment holds the transaction verdicts returned by Enterprise.
+// Risks range from 0.0 (low) to 1.0 (high).
+type FraudPreventionAssessment struct {
+	TransactionRisk      float64 `json:"transactionRisk"`
+	StolenInstrumentRisk float64 `json:"stolenInstrumentRisk"`
+	CardTestingRisk      float64 `json:"cardTestingRisk"`
+}
+
+type fraudPreventionResponse struct {
+	TransactionRisk         float64 `json:"transactionRisk"`
+	StolenInstrumentVerdict struct {
+		Risk float64 `json:"risk"`
+	} `json:"stolenInstrumentVerdict"`
+	CardTestingVerdict struct {
+		Risk float64 `json:"risk"`
+	} `json:"cardTestingVerdict"`
+}
+
+func (r *fraudPreventionResponse) toAssessment() *FraudPreventionAssessment {
+	if r == nil {
+		return nil
+	}
+	return &FraudPreventionAssessment{
+		TransactionRisk:      r.TransactionRisk,
+		StolenInstrumentRisk: r.StolenInstrumentVerdict.Risk,
+		CardTestingRisk:      r.CardTestingVerdict.Risk,
+	}
+}
+
+// Validate performs the basic checks on the transaction before calling upstream.
+func (t *TransactionData) Validate() error {
+	if t.Value < 0 || t.ShippingValue < 0 {
+		return apperrors.NewValidationError("transaction value cannot be negative", nil)
+	}
+	if t.CurrencyCode != "" && !isUpperAlpha(strings.ToUpper(t.CurrencyCode), 3) {
+		return apperrors.NewValidationError("currencyCode must be an ISO 4217 code", nil)
+	}
+	if t.CardBin != "" && (len(t.CardBin) < 6 || len(t.CardBin) > 8 || !isDigits(t.CardBin)) {
+		return apperrors.NewValidationError("cardBin must have 6 to 8 digits", nil)
+	}
+	if t.CardLastFour != "" && (len(t.CardLastFour) != 4 || !isDigits(t.CardLastFour)) {
+		return apperrors.NewValidationError("cardLastFour must have 4 digits", nil)
+	}
+	if len(t.Items) > maxTransactionItems {
+		return apperrors.NewValidationError("too many transaction items", nil)
+	}
+	for _, item := range t.Items {
+		if item.Value < 0 || item.Quantity < 0 {
+			return apperrors.NewValidationError("transaction item value and quantity cannot be negative", nil)
+		}
+	}
+	for _, field := range []string{t.TransactionID, t.PaymentMethod} {
+		if len(field) > maxTransactionFieldLen {
+			return apperrors.NewValidationError("transaction field too long", nil)
+		}
+	}
+	for _, address := range []*TransactionAddress{t.ShippingAddress, t.BillingAddress} {
+		if address != nil && address.RegionCode != "" && !isUpperAlpha(strings.ToUpper(address.RegionCode), 2) {
+			return apperrors.NewValidationError("regionCode must be an ISO 3166-1 alpha-2 code", nil)
+		}
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isUpperAlpha(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
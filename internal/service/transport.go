@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Default outbound transport settings. The idle pool per host is larger than net/http's
+// default of 2 so bursts to the same upstream reuse connections.
+const (
+	DefaultOutboundTimeout       = 10 * time.Second
+	DefaultDialTimeout           = 5 * time.Second
+	DefaultTLSHandshakeTimeout   = 5 * time.Second
+	DefaultMaxIdleConns          = 100
+	DefaultMaxIdleConnsPerHost   = 32
+	DefaultIdleConnTimeout       = 90 * time.Second
+	defaultKeepAlive             = 30 * time.Second
+	defaultExpectContinueTimeout = time.Second
+)
+
+// TransportConfig describes the HTTP client used for upstream calls.
+// Zero values take the package defaults; zero limits and timeouts not listed there are disabled.
+type TransportConfig struct {
+	// ProxyURL routes requests through an egress proxy. When empty, HTTPS_PROXY / NO_PROXY apply.
+	ProxyURL string
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// ClientCertFile and ClientKeyFile present a client certificate (mTLS).
+	ClientCertFile string
+	ClientKeyFile  string
+	// MinTLSVersion is "1.2" (default) or "1.3".
+	MinTLSVersion string
+	// DisableHTTP2 keeps connections on HTTP/1.1.
+	DisableHTTP2 bool
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+
+	// Timeout bounds a whole request; the other timeouts bound its phases.
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+func (c TransportConfig) withDefaults() TransportConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultOutboundTimeout
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = DefaultDialTimeout
+	}
+	if c.TLSHandshakeTimeout <= 0 {
+		c.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	return c
+}
+
+// NewHTTPClient builds an HTTP client from cfg. Files are read once, at construction.
+func NewHTTPClient(cfg TransportConfig) (*http.Client, error) {
+	cfg = cfg.withDefaults()
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: defaultKeepAlive}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: defaultExpectContinueTimeout,
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map turns off the automatic HTTP/2 upgrade.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Transport: transport, Timeout: cfg.Timeout}, nil
+}
+
+func (c TransportConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	switch c.MinTLSVersion {
+	case "", "1.2":
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported minimum TLS version %q", c.MinTLSVersion)
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CA bundle contains no certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" || c.ClientKeyFile != "" {
+		if c.ClientCertFile == "" || c.ClientKeyFile == "" {
+			return nil, errors.New("client certificate requires both certificate and key files")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
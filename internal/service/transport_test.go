@@ -0,0 +1,161 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes PEM blocks to a temporary file and returns its path.
+func writePEM(t *testing.T, name string, blocks ...*pem.Block) string {
+	t.Helper()
+
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, pem.EncodeToMemory(block)...)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestNewHTTPClient_CAFileAndClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			t.Error("expected a client certificate")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	// The untrusted client below aborts its handshake; keep the server quiet about it.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, "ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api-recaptcha"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certFile := writePEM(t, "client.pem", &pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyFile := writePEM(t, "client-key.pem", &pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	client, err := NewHTTPClient(TransportConfig{CAFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", resp.StatusCode)
+	}
+
+	// Without the CA bundle the test server certificate is not trusted.
+	untrusted, err := NewHTTPClient(TransportConfig{ClientCertFile: certFile, ClientKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := untrusted.Get(server.URL); err == nil {
+		t.Error("expected a certificate verification error")
+	}
+}
+
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client, err := NewHTTPClient(TransportConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := client.Get("http://upstream.invalid/v1/assessments")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if target := <-proxied; target != "http://upstream.invalid/v1/assessments" {
+		t.Errorf("expected the request to go through the proxy, got %q", target)
+	}
+}
+
+func TestNewHTTPClient_Settings(t *testing.T) {
+	client, err := NewHTTPClient(TransportConfig{
+		MinTLSVersion:         "1.3",
+		DisableHTTP2:          true,
+		MaxIdleConnsPerHost:   64,
+		ResponseHeaderTimeout: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transport := client.Transport.(*http.Transport)
+	if client.Timeout != DefaultOutboundTimeout || transport.TLSHandshakeTimeout != DefaultTLSHandshakeTimeout {
+		t.Errorf("expected default timeouts, got %v and %v", client.Timeout, transport.TLSHandshakeTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != 64 || transport.ResponseHeaderTimeout != 2*time.Second {
+		t.Errorf("unexpected pool settings: %d, %v", transport.MaxIdleConnsPerHost, transport.ResponseHeaderTimeout)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 || transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("unexpected TLS settings")
+	}
+}
+
+func TestNewHTTPClient_InvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  TransportConfig
+	}{
+		{"invalid proxy", TransportConfig{ProxyURL: "://proxy"}},
+		{"missing CA file", TransportConfig{CAFile: "/nonexistent/ca.pem"}},
+		{"certificate without key", TransportConfig{ClientCertFile: "client.pem"}},
+		{"unsupported TLS version", TransportConfig{MinTLSVersion: "1.0"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewHTTPClient(tc.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
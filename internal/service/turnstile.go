@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"api-recaptcha/internal/logger"
+)
+
+// DefaultTurnstileEndpoint is the Cloudflare Turnstile token verification endpoint.
+const DefaultTurnstileEndpoint = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+
+// TurnstileService verifies Cloudflare Turnstile tokens.
+type TurnstileService struct {
+	client   *http.Client
+	secret   string
+	endpoint string
+}
+
+// NewTurnstileService builds a TurnstileService. An empty endpoint uses DefaultTurnstileEndpoint.
+func NewTurnstileService(secret, endpoint string, opts ...VerifierOption) *TurnstileService {
+	if endpoint == "" {
+		endpoint = DefaultTurnstileEndpoint
+	}
+	return &TurnstileService{
+		client:   newVerifierConfig(opts).client,
+		secret:   secret,
+		endpoint: endpoint,
+	}
+}
+
+// Assess validates the token against Turnstile and normalizes the outcome.
+func (s *TurnstileService) Assess(ctx context.Context, token, action string) (AssessmentResult, error) {
+	if err := validateTokenInput(token, action); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	form := url.Values{}
+	form.Set("secret", s.secret)
+	form.Set("response", token)
+
+	var verification siteVerifyResponse
+	if err := postSiteVerify(ctx, s.client, s.endpoint, form, &verification); err != nil {
+		return AssessmentResult{}, err
+	}
+
+	if err := configurationError(verification.ErrorCodes, "invalid-input-secret", "missing-input-secret", "bad-request", "internal-error"); err != nil {
+		logger.Log.Error("Turnstile rejected the request", "error_codes", verification.ErrorCodes)
+		return AssessmentResult{}, err
+	}
+
+	result := verification.toResult()
+	result.Provider = ProviderTurnstile
+	if !result.Valid {
+		// Turnstile shares the classic reCAPTCHA error codes.
+		result.InvalidReason = siteVerifyInvalidReason(verification.ErrorCodes)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "api-recaptcha/internal/errors"
+)
+
+func TestTurnstileService_Assess(t *testing.T) {
+	testCases := []struct {
+		name          string
+		response      string
+		valid         bool
+		score         float64
+		invalidReason InvalidReason
+	}{
+		{
+			name:     "solved challenge is reported as 1.0",
+			response: `{"success":true,"hostname":"example.com","action":"login","challenge_ts":"2026-01-15T10:00:00.000Z"}`,
+			valid:    true,
+			score:    1,
+		},
+		{
+			name:          "expired or duplicated token",
+			response:      `{"success":false,"error-codes":["timeout-or-duplicate"]}`,
+			invalidReason: "EXPIRED",
+		},
+		{
+			name:          "malformed token",
+			response:      `{"success":false,"error-codes":["invalid-input-response"]}`,
+			invalidReason: "MALFORMED",
+		},
+		{
+			name:          "unknown error code",
+			response:      `{"success":false,"error-codes":["something-new"]}`,
+			invalidReason: "UNKNOWN_INVALID_REASON",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("failed to parse form: %v", err)
+				}
+				if r.PostForm.Get("secret") != "secret" || r.PostForm.Get("response") != "token" {
+					t.Errorf("unexpected form: %v", r.PostForm)
+				}
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			svc := NewTurnstileService("secret", server.URL)
+			result, err := svc.Assess(context.Background(), "token", "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Provider != ProviderTurnstile {
+				t.Errorf("expected provider %q, got %q", ProviderTurnstile, result.Provider)
+			}
+			if result.Valid != tc.valid {
+				t.Errorf("expected valid %v, got %v", tc.valid, result.Valid)
+			}
+			if result.Score != tc.score {
+				t.Errorf("expected score %f, got %f", tc.score, result.Score)
+			}
+			if result.InvalidReason != tc.invalidReason {
+				t.Errorf("expected invalid reason %q, got %q", tc.invalidReason, result.InvalidReason)
+			}
+		})
+	}
+}
+
+func TestTurnstileService_Assess_ConfigurationErrors(t *testing.T) {
+	for _, code := range []string{"invalid-input-secret", "missing-input-secret", "bad-request", "internal-error"} {
+		t.Run(code, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"success":false,"error-codes":["` + code + `"]}`))
+			}))
+			defer server.Close()
+
+			svc := NewTurnstileService("secret", server.URL)
+			_, err := svc.Assess(context.Background(), "token", "")
+
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrCodeRecaptchaFailed {
+				t.Fatalf("expected %s error, got %v", apperrors.ErrCodeRecaptchaFailed, err)
+			}
+			if !IsUpstreamFailure(err) {
+				t.Error("expected the error to count as an upstream failure")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package tenant
+
+import (
+	"context"
+
+	"api-recaptcha/internal/service"
+)
+
+// EnterpriseProvider is what the deployment's reCAPTCHA Enterprise provider offers.
+type EnterpriseProvider interface {
+	service.Assessor
+	service.ExpressAssessor
+	service.Annotator
+	service.PasswordLeakChecker
+}
+
+// Assessor routes Enterprise calls to the sites of the request's tenant. Requests without
+// tenant, and tenants without their own sites, go to the deployment's provider.
+type Assessor struct {
+	fallback EnterpriseProvider
+}
+
+// NewAssessor creates an Assessor falling back to the deployment's Enterprise provider.
+func NewAssessor(fallback EnterpriseProvider) *Assessor {
+	return &Assessor{fallback: fallback}
+}
+
+func (a *Assessor) target(ctx context.Context) EnterpriseProvider {
+	if t, ok := FromContext(ctx); ok && t.Sites != nil {
+		return t.Sites
+	}
+	return a.fallback
+}
+
+// Assess implements service.Assessor.
+func (a *Assessor) Assess(ctx context.Context, token, action string) (service.AssessmentResult, error) {
+	return a.target(ctx).Assess(ctx, token, action)
+}
+
+// Scope implements service.Scoper with the scope of the sites serving the request.
+func (a *Assessor) Scope(ctx context.Context) (string, error) {
+	if scoper, ok := a.target(ctx).(service.Scoper); ok {
+		return scoper.Scope(ctx)
+	}
+	return "", nil
+}
+
+// AssessExpress implements service.ExpressAssessor.
+func (a *Assessor) AssessExpress(ctx context.Context, action string) (service.AssessmentResult, error) {
+	return a.target(ctx).AssessExpress(ctx, action)
+}
+
+// Annotate implements service.Annotator.
+func (a *Assessor) Annotate(ctx context.Context, assessmentID string, annotation service.Annotation, reasons []service.AnnotationReason) error {
+	return a.target(ctx).Annotate(ctx, assessmentID, annotation, reasons)
+}
+
+// CheckPasswordLeak implements service.PasswordLeakChecker.
+func (a *Assessor) CheckPasswordLeak(ctx context.Context, username, password string) (bool, error) {
+	return a.target(ctx).CheckPasswordLeak(ctx, username, password)
+}
@@ -0,0 +1,142 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotFound is returned when a tenant does not exist in the store.
+var ErrNotFound = errors.New("tenant not found")
+
+// Store persists tenant configurations.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	List() ([]Config, error)
+	Get(id string) (Config, error)
+	// Put creates the tenant or replaces the one with the same ID.
+	Put(cfg Config) error
+	Delete(id string) error
+}
+
+// FileStore keeps tenants in a local JSON file. Writes replace the file atomically.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+type storeFile struct {
+	Tenants []Config `json:"tenants"`
+}
+
+// NewFileStore returns a store backed by the file at path. Reads fail with an error wrapping
+// os.ErrNotExist until the file exists; Put creates it.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// List returns every stored tenant.
+func (s *FileStore) List() ([]Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// Get returns the tenant with the given ID.
+func (s *FileStore) Get(id string) (Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenants, err := s.load()
+	if err != nil {
+		return Config{}, err
+	}
+	for _, cfg := range tenants {
+		if cfg.ID == id {
+			return cfg, nil
+		}
+	}
+	return Config{}, ErrNotFound
+}
+
+// Put validates and stores cfg.
+func (s *FileStore) Put(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenants, err := s.load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := range tenants {
+		if tenants[i].ID == cfg.ID {
+			tenants[i] = cfg
+			return s.save(tenants)
+		}
+	}
+	return s.save(append(tenants, cfg))
+}
+
+// Delete removes the tenant with the given ID.
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenants, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i := range tenants {
+		if tenants[i].ID == id {
+			return s.save(append(tenants[:i], tenants[i+1:]...))
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *FileStore) load() ([]Config, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read tenants file: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse tenants file: %w", err)
+	}
+	return file.Tenants, nil
+}
+
+func (s *FileStore) save(tenants []Config) error {
+	data, err := json.MarshalIndent(storeFile{Tenants: tenants}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode tenants file: %w", err)
+	}
+
+	// Write to a temporary file in the same directory and rename it, so readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".tenants-*.json")
+	if err != nil {
+		return fmt.Errorf("write tenants file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write tenants file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write tenants file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write tenants file: %w", err)
+	}
+	return nil
+}
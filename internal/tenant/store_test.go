@@ -0,0 +1,65 @@
+package tenant
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	store := NewFileStore(path)
+
+	// A missing file is an error, not an empty store, so a mistyped path is noticed.
+	if _, err := store.List(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist for a missing file, got %v", err)
+	}
+
+	if err := store.Put(Config{ID: "payments", APIKeys: []string{"key-1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Put(Config{ID: "support", APIKeys: []string{"key-2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Put(Config{ID: "payments", Name: "Payments", APIKeys: []string{"key-3"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new store on the same file sees the persisted tenants.
+	reopened := NewFileStore(path)
+	tenants, err := reopened.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tenants) != 2 {
+		t.Fatalf("expected 2 tenants, got %d", len(tenants))
+	}
+
+	payments, err := reopened.Get("payments")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payments.Name != "Payments" || payments.APIKeys[0] != "key-3" {
+		t.Errorf("expected replaced tenant, got %+v", payments)
+	}
+
+	if err := reopened.Delete("support"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Get("support"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Delete("support"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.Put(Config{ID: "invalid"}); err == nil {
+		t.Error("expected an error for a tenant without API key")
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the tenants file, found %d entries", len(entries))
+	}
+}
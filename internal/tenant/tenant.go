@@ -0,0 +1,229 @@
+// Package tenant lets several teams share one deployment. Each tenant authenticates with its own
+// app API keys and may bring its own reCAPTCHA Enterprise sites, CORS origins, rate limit and
+// score policies; anything a tenant leaves out falls back to the deployment configuration.
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"api-recaptcha/internal/policy"
+	"api-recaptcha/internal/service"
+)
+
+// Config is the stored representation of a tenant.
+type Config struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	APIKeys []string `json:"apiKeys"`
+
+	// Sites holds the tenant's reCAPTCHA Enterprise site keys and Google credentials.
+	// Tenants without sites use the deployment's Enterprise provider.
+	Sites       []service.SiteConfig `json:"sites,omitempty"`
+	DefaultSite string               `json:"defaultSite,omitempty"`
+
+	// CORSOrigins restricts the browser origins allowed to call the API with the tenant's keys.
+	CORSOrigins []string       `json:"corsOrigins,omitempty"`
+	RateLimit   *RateLimit     `json:"rateLimit,omitempty"`
+	Policies    *policy.Config `json:"policies,omitempty"`
+}
+
+// RateLimit is the number of requests a client may send in a window.
+type RateLimit struct {
+	Requests      int `json:"requests"`
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+// Window returns the rate limit window as a duration.
+func (r RateLimit) Window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Validate checks the fields every tenant needs.
+func (c Config) Validate() error {
+	if !tenantIDPattern.MatchString(c.ID) {
+		return fmt.Errorf("invalid tenant id %q", c.ID)
+	}
+	if len(c.APIKeys) == 0 {
+		return fmt.Errorf("tenant %q has no API key", c.ID)
+	}
+	for _, key := range c.APIKeys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("tenant %q has an empty API key", c.ID)
+		}
+	}
+	if c.RateLimit != nil && (c.RateLimit.Requests <= 0 || c.RateLimit.WindowSeconds <= 0) {
+		return fmt.Errorf("tenant %q: rate limit requires positive requests and windowSeconds", c.ID)
+	}
+	return nil
+}
+
+// Tenant is a tenant ready to serve requests.
+type Tenant struct {
+	ID   string
+	Name string
+
+	// Sites routes the tenant's Enterprise calls; nil uses the deployment's provider.
+	Sites *service.SiteRegistry
+	// Policies evaluates the tenant's assessments; nil uses the deployment's policies.
+	Policies *policy.Engine
+	// RateLimit limits each client of the tenant, in addition to the deployment rate limit.
+	RateLimit *RateLimit
+
+	origins map[string]bool
+}
+
+// AllowsOrigin reports whether a request from origin may use the tenant's keys. Requests
+// without Origin header (server to server) and tenants without CORS origins are always allowed.
+func (t *Tenant) AllowsOrigin(origin string) bool {
+	if origin == "" || len(t.origins) == 0 {
+		return true
+	}
+	return t.origins[strings.TrimRight(origin, "/")]
+}
+
+// SiteBuilder creates the Enterprise clients of a tenant's sites.
+type SiteBuilder func(tenantID string, sites service.SitesConfig) (*service.SiteRegistry, error)
+
+// Directory holds the tenants of the deployment, indexed by API key.
+type Directory struct {
+	tenants []*Tenant
+	byKey   map[[sha256.Size]byte]*Tenant
+	origins []string
+}
+
+// NewDirectory validates the tenant configurations and builds their runtime state.
+// API keys are indexed by their SHA-256 hash so lookups do not leak key prefixes through timing.
+func NewDirectory(configs []Config, buildSites SiteBuilder) (*Directory, error) {
+	d := &Directory{byKey: make(map[[sha256.Size]byte]*Tenant)}
+	seenIDs := make(map[string]bool)
+	seenOrigins := make(map[string]bool)
+
+	for _, cfg := range configs {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		if seenIDs[cfg.ID] {
+			return nil, fmt.Errorf("duplicate tenant %q", cfg.ID)
+		}
+		seenIDs[cfg.ID] = true
+
+		t := &Tenant{ID: cfg.ID, Name: cfg.Name, RateLimit: cfg.RateLimit, origins: make(map[string]bool)}
+
+		if len(cfg.Sites) > 0 {
+			defaultSite := cfg.DefaultSite
+			if defaultSite == "" {
+				defaultSite = cfg.Sites[0].Name
+			}
+			sites, err := buildSites(cfg.ID, service.SitesConfig{Default: defaultSite, Sites: cfg.Sites})
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", cfg.ID, err)
+			}
+			t.Sites = sites
+		}
+
+		if cfg.Policies != nil {
+			engine, err := policy.NewEngine(*cfg.Policies)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", cfg.ID, err)
+			}
+			t.Policies = engine
+		}
+
+		for _, origin := range cfg.CORSOrigins {
+			origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+			t.origins[origin] = true
+			if !seenOrigins[origin] {
+				seenOrigins[origin] = true
+				d.origins = append(d.origins, origin)
+			}
+		}
+
+		for _, key := range cfg.APIKeys {
+			hash := sha256.Sum256([]byte(key))
+			if owner, exists := d.byKey[hash]; exists {
+				return nil, fmt.Errorf("tenant %q: API key already belongs to tenant %q", cfg.ID, owner.ID)
+			}
+			d.byKey[hash] = t
+		}
+
+		d.tenants = append(d.tenants, t)
+	}
+
+	sort.Strings(d.origins)
+	return d, nil
+}
+
+// Authenticate returns the tenant owning apiKey.
+func (d *Directory) Authenticate(apiKey string) (*Tenant, bool) {
+	if d == nil || apiKey == "" {
+		return nil, false
+	}
+	t, ok := d.byKey[sha256.Sum256([]byte(apiKey))]
+	return t, ok
+}
+
+// Tenants returns the tenants in configuration order.
+func (d *Directory) Tenants() []*Tenant {
+	return d.tenants
+}
+
+// Origins returns the CORS origins of every tenant.
+func (d *Directory) Origins() []string {
+	return d.origins
+}
+
+// SiteKeys returns the Enterprise site keys of every tenant.
+func (d *Directory) SiteKeys() []string {
+	var keys []string
+	for _, t := range d.tenants {
+		if t.Sites != nil {
+			keys = append(keys, t.Sites.SiteKeys()...)
+		}
+	}
+	return keys
+}
+
+// ginContextKey is the gin context key holding the request's tenant.
+const ginContextKey = "tenant"
+
+type contextKey struct{}
+
+// Attach stores t in the gin context and in the request context, so both handlers and
+// services down the call chain can find it.
+func Attach(c *gin.Context, t *Tenant) {
+	c.Set(ginContextKey, t)
+	c.Request = c.Request.WithContext(NewContext(c.Request.Context(), t))
+}
+
+// FromGinContext returns the tenant authenticated for the request, if any.
+func FromGinContext(c *gin.Context) (*Tenant, bool) {
+	value, ok := c.Get(ginContextKey)
+	if !ok {
+		return nil, false
+	}
+	t, ok := value.(*Tenant)
+	return t, ok
+}
+
+// NewContext returns a copy of ctx carrying t. The tenant is also the service partition of ctx,
+// so assessments are never shared between tenants.
+func NewContext(ctx context.Context, t *Tenant) context.Context {
+	ctx = service.ContextWithPartition(ctx, "tenant:"+t.ID)
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant carried by ctx, if any.
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(*Tenant)
+	return t, ok
+}
@@ -0,0 +1,133 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"api-recaptcha/internal/policy"
+	"api-recaptcha/internal/service"
+)
+
+func buildTestSites(tenantID string, cfg service.SitesConfig) (*service.SiteRegistry, error) {
+	registry := service.NewSiteRegistry(cfg.Default)
+	for _, site := range cfg.Sites {
+		svc := service.NewRecaptchaService("key", site.SiteKey, "http://"+tenantID)
+		if err := registry.Register(site.Name, svc, site.Origins, site.AppAPIKeys); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+func TestNewDirectory(t *testing.T) {
+	directory, err := NewDirectory([]Config{
+		{
+			ID:          "payments",
+			APIKeys:     []string{"payments-key"},
+			Sites:       []service.SiteConfig{{Name: "checkout", SiteKey: "checkout-key", ProjectID: "payments-project"}},
+			CORSOrigins: []string{"https://pay.example.com/"},
+			RateLimit:   &RateLimit{Requests: 10, WindowSeconds: 60},
+			Policies:    &policy.Config{Rules: []policy.Rule{{Action: "pay", AllowScore: 0.8, ChallengeScore: 0.5}}},
+		},
+		{ID: "support", APIKeys: []string{"support-key", "support-key-2"}},
+	}, buildTestSites)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payments, ok := directory.Authenticate("payments-key")
+	if !ok || payments.ID != "payments" {
+		t.Fatalf("expected payments tenant, got %+v", payments)
+	}
+	if payments.Sites == nil || payments.Policies == nil || payments.RateLimit.Requests != 10 {
+		t.Errorf("expected tenant sites, policies and rate limit, got %+v", payments)
+	}
+	if !payments.AllowsOrigin("https://pay.example.com") || payments.AllowsOrigin("https://evil.example.com") {
+		t.Error("unexpected origin check")
+	}
+	if !payments.AllowsOrigin("") {
+		t.Error("expected requests without origin to be allowed")
+	}
+
+	support, ok := directory.Authenticate("support-key-2")
+	if !ok || support.ID != "support" || support.Sites != nil || support.Policies != nil {
+		t.Errorf("expected support tenant without overrides, got %+v", support)
+	}
+	if !support.AllowsOrigin("https://any.example.com") {
+		t.Error("expected tenant without origins to allow any origin")
+	}
+
+	if _, ok := directory.Authenticate("unknown"); ok {
+		t.Error("expected unknown key to be rejected")
+	}
+	if got := directory.Origins(); len(got) != 1 || got[0] != "https://pay.example.com" {
+		t.Errorf("unexpected origins %v", got)
+	}
+	if got := directory.SiteKeys(); len(got) != 1 || got[0] != "checkout-key" {
+		t.Errorf("unexpected site keys %v", got)
+	}
+}
+
+func TestNewDirectory_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		configs []Config
+	}{
+		{"invalid id", []Config{{ID: "Team A", APIKeys: []string{"key"}}}},
+		{"no API key", []Config{{ID: "team"}}},
+		{"duplicate tenant", []Config{{ID: "team", APIKeys: []string{"a"}}, {ID: "team", APIKeys: []string{"b"}}}},
+		{"shared API key", []Config{{ID: "a", APIKeys: []string{"key"}}, {ID: "b", APIKeys: []string{"key"}}}},
+		{"invalid rate limit", []Config{{ID: "team", APIKeys: []string{"key"}, RateLimit: &RateLimit{Requests: 10}}}},
+		{"invalid policies", []Config{{ID: "team", APIKeys: []string{"key"}, Policies: &policy.Config{Rules: []policy.Rule{{Action: "login", AllowScore: 2}}}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewDirectory(tc.configs, buildTestSites); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestAssessor_RoutesByTenant(t *testing.T) {
+	newServer := func(score string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"tokenProperties": {"valid": true, "action": "login"}, "riskAnalysis": {"score": ` + score + `}}`))
+		}))
+	}
+	shared := newServer("0.1")
+	defer shared.Close()
+	own := newServer("0.9")
+	defer own.Close()
+
+	sites := service.NewSiteRegistry("main")
+	if err := sites.Register("main", service.NewRecaptchaService("key", "tenant-key", own.URL), nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assessor := NewAssessor(service.NewRecaptchaService("key", "shared-key", shared.URL))
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		expected float64
+	}{
+		{"no tenant", context.Background(), 0.1},
+		{"tenant without sites", NewContext(context.Background(), &Tenant{ID: "plain"}), 0.1},
+		{"tenant with sites", NewContext(context.Background(), &Tenant{ID: "own", Sites: sites}), 0.9},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := assessor.Assess(tc.ctx, "token", "login")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Score != tc.expected {
+				t.Errorf("expected score %v, got %v", tc.expected, result.Score)
+			}
+		})
+	}
+}